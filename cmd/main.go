@@ -2,6 +2,9 @@ package main
 
 import (
 	"SYBD/internal/api"
+	"SYBD/internal/db"
+	"SYBD/internal/db/cache"
+	"SYBD/internal/db/memory"
 	"context"
 	"log"
 	"os"
@@ -14,8 +17,15 @@ import (
 )
 
 const (
-	defaultAddress = "0.0.0.0"
-	defaultPort    = "8080"
+	defaultAddress  = "0.0.0.0"
+	defaultPort     = "8080"
+	defaultDBDriver = "postgres"
+	defaultTokenTTL = "24h"
+	// defaultRetention is how long streamed events can be resumed from.
+	defaultRetention = "1h"
+	// defaultReadYourWrites must exceed the replication lag.
+	defaultReadYourWrites       = "5s"
+	defaultReplicaCheckInterval = "5s"
 )
 
 func main() {
@@ -30,6 +40,12 @@ func main() {
 
 	viper.SetDefault("service.bind.address", defaultAddress)
 	viper.SetDefault("service.bind.port", defaultPort)
+	viper.SetDefault("db.driver", defaultDBDriver)
+	viper.SetDefault("auth.token_ttl", defaultTokenTTL)
+	viper.SetDefault("auth.legacy", true)
+	viper.SetDefault("stream.retention", defaultRetention)
+	viper.SetDefault("db.read_your_writes", defaultReadYourWrites)
+	viper.SetDefault("db.replica_check_interval", defaultReplicaCheckInterval)
 
 	// -------------------- Set up logging -------------------- //
 
@@ -57,14 +73,71 @@ func main() {
 
 	// -------------------- Set up database -------------------- //
 
-	db, err := pgxpool.Connect(context.Background(), viper.GetString("db.connection_string"))
-	if err != nil {
-		log.Fatalf("unable to connect to database: %s", err)
+	migrateCmd := len(os.Args) > 1 && os.Args[1] == "migrate"
+
+	var repository *db.Repository
+	switch driver := viper.GetString("db.driver"); driver {
+	case "memory":
+		if migrateCmd {
+			log.Fatal("migrations are not supported by the in-memory storage")
+		}
+		log.Warn("using in-memory storage, data will be lost on shutdown")
+		repository = memory.NewRepository()
+	case "postgres":
+		pool, err := pgxpool.Connect(context.Background(), viper.GetString("db.connection_string"))
+		if err != nil {
+			log.Fatalf("unable to connect to database: %s", err)
+		}
+		defer pool.Close()
+
+		if migrateCmd {
+			if err := runMigrations(context.Background(), log, pool, os.Args[2:]); err != nil {
+				log.Fatalf("migration failed: %s", err)
+			}
+			return
+		}
+
+		if viper.GetBool("db.auto_migrate") {
+			if err := runMigrations(context.Background(), log, pool, []string{"up"}); err != nil {
+				log.Fatalf("migration failed: %s", err)
+			}
+		}
+
+		var replicas []*pgxpool.Pool
+		for _, connString := range viper.GetStringSlice("db.replicas") {
+			replica, err := pgxpool.Connect(context.Background(), connString)
+			if err != nil {
+				log.Fatalf("unable to connect to replica: %s", err)
+			}
+			defer replica.Close()
+			replicas = append(replicas, replica)
+		}
+
+		router := db.NewRouter(pool, replicas, viper.GetDuration("db.read_your_writes"))
+		monitor, stopMonitor := context.WithCancel(context.Background())
+		defer stopMonitor()
+		go router.Monitor(monitor, viper.GetDuration("db.replica_check_interval"))
+
+		if repository, err = db.NewRepository(router); err != nil {
+			log.Fatalf("unable to create repository: %s", err)
+		}
+		if rebuilt, err := repository.StatsRepo.RepairForumStats(context.Background()); err != nil {
+			log.Fatalf("unable to check forum stats: %s", err)
+		} else if rebuilt {
+			log.Warn("forum stats disagreed with the forum counters and were rebuilt")
+		}
+	default:
+		log.Fatalf("unknown database driver: %s", driver)
+	}
+
+	var caches cache.Configs
+	if err := viper.UnmarshalKey("cache", &caches); err != nil {
+		log.Fatalf("invalid cache config: %s", err)
 	}
-	defer db.Close()
+	repository = cache.Wrap(repository, caches)
 
 	// -------------------- Set up service -------------------- //
-	svc, err := api.NewAPIService(logrus.NewEntry(log), db)
+	svc, err := api.NewAPIService(logrus.NewEntry(log), repository)
 	if err != nil {
 		log.Fatalf("error creating service instance: %s", err)
 	}
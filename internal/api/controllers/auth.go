@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"SYBD/internal/model/dto"
+	"SYBD/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type AuthController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *AuthController) Login(ctx echo.Context) error {
+	request := &dto.LoginRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.AuthService.Login(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *AuthController) SetPassword(ctx echo.Context) error {
+	request := &dto.SetPasswordRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.AuthService.SetPassword(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func NewAuthController(log *logrus.Entry, registry *service.Registry) *AuthController {
+	return &AuthController{log: log, registry: registry}
+}
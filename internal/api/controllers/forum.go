@@ -3,7 +3,6 @@ package controllers
 import (
 	"SYBD/internal/model/dto"
 	"SYBD/internal/service"
-	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -19,7 +18,7 @@ func (c *ForumController) CreateForum(ctx echo.Context) error {
 		return err
 	}
 
-	response, err := c.registry.ForumService.CreateForum(context.Background(), request)
+	response, err := c.registry.ForumService.CreateForum(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -34,7 +33,7 @@ func (c *ForumController) GetForum(ctx echo.Context) error {
 	}
 	request.Slug = ctx.Param("slug")
 
-	response, err := c.registry.ForumService.GetForum(context.Background(), request)
+	response, err := c.registry.ForumService.GetForum(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -54,11 +53,11 @@ func (c *ForumController) GetForumThreads(ctx echo.Context) error {
 		request.Limit = 100
 	}
 
-	response, err := c.registry.ForumService.GetThread(context.Background(), request)
+	response, err := c.registry.ForumService.GetThread(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Value)
+	return respondPage(ctx, response.Code, response.Value, response.NextCursor)
 }
 
 func (c *ForumController) GetUsers(ctx echo.Context) error {
@@ -73,7 +72,79 @@ func (c *ForumController) GetUsers(ctx echo.Context) error {
 		request.Limit = 100
 	}
 
-	response, err := c.registry.ForumService.GetUsers(context.Background(), request)
+	response, err := c.registry.ForumService.GetUsers(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return respondPage(ctx, response.Code, response.Value, response.NextCursor)
+}
+
+func (c *ForumController) UpdateForum(ctx echo.Context) error {
+	request := &dto.UpdateForumRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Slug = ctx.Param("slug")
+
+	response, err := c.registry.ForumService.UpdateForum(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ForumController) DeleteForum(ctx echo.Context) error {
+	request := &dto.DeleteForumRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Slug = ctx.Param("slug")
+
+	response, err := c.registry.ForumService.DeleteForum(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ForumController) GetStats(ctx echo.Context) error {
+	request := new(dto.GetForumStatsRequest)
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Slug = ctx.Param("slug")
+
+	response, err := c.registry.ForumService.GetStats(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ForumController) GetModerators(ctx echo.Context) error {
+	request := &dto.GetForumRequest{Slug: ctx.Param("slug")}
+
+	response, err := c.registry.ForumService.GetModerators(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ForumController) AddModerator(ctx echo.Context) error {
+	request := &dto.ModeratorRequest{Slug: ctx.Param("slug"), Nickname: ctx.Param("nickname")}
+
+	response, err := c.registry.ForumService.AddModerator(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ForumController) RemoveModerator(ctx echo.Context) error {
+	request := &dto.ModeratorRequest{Slug: ctx.Param("slug"), Nickname: ctx.Param("nickname")}
+
+	response, err := c.registry.ForumService.RemoveModerator(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
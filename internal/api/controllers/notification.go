@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"SYBD/internal/model/dto"
+	"SYBD/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *NotificationController) GetNotifications(ctx echo.Context) error {
+	request := &dto.GetNotificationsRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.NotificationService.GetNotifications(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	setNextLink(ctx, response.NextCursor)
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *NotificationController) MarkRead(ctx echo.Context) error {
+	request := &dto.MarkReadRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.NotificationService.MarkRead(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func NewNotificationController(log *logrus.Entry, registry *service.Registry) *NotificationController {
+	return &NotificationController{log: log, registry: registry}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"SYBD/internal/model/dto"
+	"fmt"
+	"github.com/labstack/echo/v4"
+)
+
+// respondPage writes one page of a cursor-paginated listing. The next page is
+// always advertised in the Link header; clients which send the cursor query
+// parameter (empty for the first page) get the dto.Page envelope, everyone
+// else the plain array of the legacy API.
+func respondPage(ctx echo.Context, code int, items interface{}, nextCursor string) error {
+	setNextLink(ctx, nextCursor)
+
+	if !ctx.QueryParams().Has("cursor") {
+		return ctx.JSON(code, items)
+	}
+	return ctx.JSON(code, dto.Page{Items: items, NextCursor: nextCursor})
+}
+
+// setNextLink advertises the page after nextCursor in the Link header.
+func setNextLink(ctx echo.Context, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+
+	query := ctx.Request().URL.Query()
+	query.Del("since")
+	query.Set("cursor", nextCursor)
+
+	next := *ctx.Request().URL
+	next.RawQuery = query.Encode()
+	ctx.Response().Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
@@ -1,11 +1,12 @@
 package controllers
 
 import (
+	"SYBD/internal/constants"
 	"SYBD/internal/model/dto"
 	"SYBD/internal/service"
 	"bytes"
-	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"strconv"
@@ -24,10 +25,12 @@ func (c *PostController) CreatePost(ctx echo.Context) error {
 		//log.Errorf("Unmarshal error: %s", err)
 		return err
 	}
-	err = json.Unmarshal(buf.Bytes(), &request)
+	if err = json.Unmarshal(buf.Bytes(), &request); err != nil {
+		return fmt.Errorf("%w: %v", constants.ErrBadJson, err)
+	}
 
 	slugOrID := ctx.Param("slug_or_id")
-	response, err := c.registry.PostService.CreatePost(context.Background(), slugOrID, request)
+	response, err := c.registry.PostService.CreatePost(ctx.Request().Context(), slugOrID, request)
 	if err != nil {
 		return err
 	}
@@ -57,17 +60,19 @@ func (c *PostController) GetPost(ctx echo.Context) error {
 	limitInt, _ := strconv.ParseInt(limit, 10, 64)
 	descBool, _ := strconv.ParseBool(ctx.QueryParam("desc"))
 
-	response, err := c.registry.PostService.GetPost(context.Background(),
-		slugOrID,
-		sort,
-		sinceInt,
-		descBool,
-		limitInt)
+	response, err := c.registry.PostService.GetPost(ctx.Request().Context(), &dto.GetPostRequest{
+		SlugOrID: slugOrID,
+		Sort:     sort,
+		Since:    sinceInt,
+		Cursor:   ctx.QueryParam("cursor"),
+		Desc:     descBool,
+		Limit:    limitInt,
+	})
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Value)
+	return respondPage(ctx, response.Code, response.Value, response.NextCursor)
 }
 
 func (c *PostController) GetPostDetails(ctx echo.Context) error {
@@ -76,10 +81,13 @@ func (c *PostController) GetPostDetails(ctx echo.Context) error {
 		//c.log.Errorf("Bind error: %s", err)
 		return err
 	}
-	id, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
 	request.ID = id
 
-	response, err := c.registry.PostService.GetPostDetails(context.Background(), request)
+	response, err := c.registry.PostService.GetPostDetails(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -89,11 +97,103 @@ func (c *PostController) GetPostDetails(ctx echo.Context) error {
 
 func (c *PostController) UpdatePost(ctx echo.Context) error {
 	request := &dto.UpdatePostRequest{}
-	request.ID, _ = strconv.ParseInt(ctx.Param("id"), 10, 64)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+	request.ID = id
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	response, err := c.registry.PostService.UpdatePost(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) UpdateVote(ctx echo.Context) error {
+	request := &dto.UpdatePostVoteRequest{}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
 	if err := ctx.Bind(request); err != nil {
 		return err
 	}
-	response, err := c.registry.PostService.UpdatePost(context.Background(), request)
+	request.ID = id
+
+	response, err := c.registry.PostService.UpdateVote(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) GetPostHistory(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+
+	response, err := c.registry.PostService.GetPostHistory(ctx.Request().Context(), &dto.GetPostHistoryRequest{ID: id})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) RollbackPost(ctx echo.Context) error {
+	request := &dto.RollbackPostRequest{}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.ID = id
+
+	response, err := c.registry.PostService.RollbackPost(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) DeletePost(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+
+	response, err := c.registry.PostService.DeletePost(ctx.Request().Context(), &dto.DeletePostRequest{ID: id})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) DeletePostTree(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+
+	response, err := c.registry.PostService.DeletePostTree(ctx.Request().Context(), &dto.DeletePostRequest{ID: id})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *PostController) RestorePost(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid post id: %s", ctx.Param("id"))
+	}
+
+	response, err := c.registry.PostService.RestorePost(ctx.Request().Context(), &dto.RestorePostRequest{ID: id})
 	if err != nil {
 		return err
 	}
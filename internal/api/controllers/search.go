@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"SYBD/internal/model/dto"
+	"SYBD/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type SearchController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *SearchController) Search(ctx echo.Context) error {
+	request := &dto.SearchRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+
+	response, err := c.registry.SearchService.Search(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	setNextLink(ctx, response.NextCursor)
+	return ctx.JSON(response.Code, dto.Page{Items: response.Value, NextCursor: response.NextCursor})
+}
+
+func NewSearchController(log *logrus.Entry, registry *service.Registry) *SearchController {
+	return &SearchController{log: log, registry: registry}
+}
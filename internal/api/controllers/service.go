@@ -1,34 +1,40 @@
 package controllers
 
 import (
-	"SYBD/internal/db"
-	"context"
+	"SYBD/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
-	"net/http"
 )
 
 type ServiceController struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log      *logrus.Entry
+	registry *service.Registry
 }
 
 func (c *ServiceController) Status(ctx echo.Context) error {
-	response, err := c.db.ServiceRepo.Status(context.Background())
+	response, err := c.registry.AdminService.Status(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(http.StatusOK, response)
+	return ctx.JSON(response.Code, response.Value)
 }
 
 func (c *ServiceController) Delete(ctx echo.Context) error {
-	err := c.db.ServiceRepo.Delete(context.Background())
+	response, err := c.registry.AdminService.Clear(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(http.StatusOK, nil)
+	return ctx.JSON(response.Code, response.Value)
 }
 
-func NewServiceController(log *logrus.Entry, db *db.Repository) *ServiceController {
-	return &ServiceController{log: log, db: db}
+func (c *ServiceController) Metrics(ctx echo.Context) error {
+	response, err := c.registry.AdminService.Metrics(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func NewServiceController(log *logrus.Entry, registry *service.Registry) *ServiceController {
+	return &ServiceController{log: log, registry: registry}
 }
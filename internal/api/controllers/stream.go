@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"SYBD/internal/model/core"
+	"SYBD/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// streamHeartbeat is how often an idle event stream sends a comment, so
+	// proxies keep the connection open.
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout bounds how long a websocket client may take to
+	// accept an event.
+	streamWriteTimeout = 10 * time.Second
+)
+
+type StreamController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *StreamController) ThreadEvents(ctx echo.Context) error {
+	after, err := lastEventID(ctx)
+	if err != nil {
+		return err
+	}
+	sub, err := c.registry.StreamService.SubscribeThread(ctx.Request().Context(), ctx.Param("slug_or_id"), after)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	return c.serveEventStream(ctx, sub)
+}
+
+func (c *StreamController) ThreadWebSocket(ctx echo.Context) error {
+	after, err := lastEventID(ctx)
+	if err != nil {
+		return err
+	}
+	sub, err := c.registry.StreamService.SubscribeThread(ctx.Request().Context(), ctx.Param("slug_or_id"), after)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	return c.serveWebSocket(ctx, sub)
+}
+
+func (c *StreamController) ForumEvents(ctx echo.Context) error {
+	after, err := lastEventID(ctx)
+	if err != nil {
+		return err
+	}
+	sub, err := c.registry.StreamService.SubscribeForum(ctx.Request().Context(), ctx.Param("slug"), after)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	return c.serveEventStream(ctx, sub)
+}
+
+func (c *StreamController) ForumWebSocket(ctx echo.Context) error {
+	after, err := lastEventID(ctx)
+	if err != nil {
+		return err
+	}
+	sub, err := c.registry.StreamService.SubscribeForum(ctx.Request().Context(), ctx.Param("slug"), after)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	return c.serveWebSocket(ctx, sub)
+}
+
+// serveEventStream writes events as Server-Sent Events until the client goes
+// away. The event cursor lets clients resume through the Last-Event-ID header.
+func (c *StreamController) serveEventStream(ctx echo.Context, sub *service.Subscription) error {
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	reqCtx := ctx.Request().Context()
+	for {
+		waitCtx, cancel := context.WithTimeout(reqCtx, streamHeartbeat)
+		event, err := sub.Next(waitCtx)
+		cancel()
+
+		switch {
+		case err == nil:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", event.Cursor, event.Type, data); err != nil {
+				return nil
+			}
+		case errors.Is(err, context.DeadlineExceeded) && reqCtx.Err() == nil:
+			if _, err := io.WriteString(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+		default:
+			if reqCtx.Err() == nil && !errors.Is(err, service.ErrStreamClosed) {
+				c.log.Warnf("event stream failed: %s", err)
+			}
+			return nil
+		}
+		res.Flush()
+	}
+}
+
+// serveWebSocket sends events as JSON text frames until the client goes
+// away. Messages from the client are ignored.
+func (c *StreamController) serveWebSocket(ctx echo.Context, sub *service.Subscription) error {
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		wsCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_, _ = io.Copy(io.Discard, ws)
+			cancel()
+		}()
+
+		for {
+			event, err := sub.Next(wsCtx)
+			if err != nil {
+				if wsCtx.Err() == nil && !errors.Is(err, service.ErrStreamClosed) {
+					c.log.Warnf("websocket stream failed: %s", err)
+				}
+				return
+			}
+			_ = ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+// lastEventID returns the cursor of the last event the client has seen, from
+// the Last-Event-ID header of reconnecting event sources or the last_event_id
+// query parameter.
+func lastEventID(ctx echo.Context) (core.EventPosition, error) {
+	value := ctx.Request().Header.Get("Last-Event-ID")
+	if value == "" {
+		value = ctx.QueryParam("last_event_id")
+	}
+	if value == "" {
+		return core.EventPosition{}, nil
+	}
+	return core.ParseEventPosition(value)
+}
+
+func NewStreamController(log *logrus.Entry, registry *service.Registry) *StreamController {
+	return &StreamController{log: log, registry: registry}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"SYBD/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type SubscriptionController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *SubscriptionController) GetSubscriptions(ctx echo.Context) error {
+	request := &dto.GetSubscriptionsRequest{Nickname: ctx.Param("nickname")}
+
+	response, err := c.registry.SubscriptionService.GetSubscriptions(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *SubscriptionController) SubscribeThread(ctx echo.Context) error {
+	return c.subscribe(ctx, core.SubscriptionThread, ctx.Param("slug_or_id"))
+}
+
+func (c *SubscriptionController) SubscribeForum(ctx echo.Context) error {
+	return c.subscribe(ctx, core.SubscriptionForum, ctx.Param("slug"))
+}
+
+func (c *SubscriptionController) UnsubscribeThread(ctx echo.Context) error {
+	return c.unsubscribe(ctx, core.SubscriptionThread, ctx.Param("slug_or_id"))
+}
+
+func (c *SubscriptionController) UnsubscribeForum(ctx echo.Context) error {
+	return c.unsubscribe(ctx, core.SubscriptionForum, ctx.Param("slug"))
+}
+
+func (c *SubscriptionController) subscribe(ctx echo.Context, kind string, target string) error {
+	request := &dto.SubscriptionRequest{Nickname: ctx.Param("nickname"), Kind: kind, Target: target}
+
+	response, err := c.registry.SubscriptionService.Subscribe(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *SubscriptionController) unsubscribe(ctx echo.Context, kind string, target string) error {
+	request := &dto.SubscriptionRequest{Nickname: ctx.Param("nickname"), Kind: kind, Target: target}
+
+	response, err := c.registry.SubscriptionService.Unsubscribe(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *SubscriptionController) GetFeed(ctx echo.Context) error {
+	request := &dto.GetFeedRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.SubscriptionService.GetFeed(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func NewSubscriptionController(log *logrus.Entry, registry *service.Registry) *SubscriptionController {
+	return &SubscriptionController{log: log, registry: registry}
+}
@@ -3,7 +3,6 @@ package controllers
 import (
 	"SYBD/internal/model/dto"
 	"SYBD/internal/service"
-	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -20,7 +19,7 @@ func (c *ThreadController) CreateThread(ctx echo.Context) error {
 	}
 	request.Forum = ctx.Param("slug")
 
-	response, err := c.registry.ThreadService.CreateThread(context.Background(), request)
+	response, err := c.registry.ThreadService.CreateThread(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -36,7 +35,7 @@ func (c *ThreadController) UpdateVote(ctx echo.Context) error {
 	}
 
 	slugOrID := ctx.Param("slug_or_id")
-	response, err := c.registry.ThreadService.UpdateVote(context.Background(), slugOrID, request)
+	response, err := c.registry.ThreadService.UpdateVote(ctx.Request().Context(), slugOrID, request)
 	if err != nil {
 		return err
 	}
@@ -44,9 +43,35 @@ func (c *ThreadController) UpdateVote(ctx echo.Context) error {
 	return ctx.JSON(response.Code, response.Value)
 }
 
+func (c *ThreadController) GetVotes(ctx echo.Context) error {
+	request := &dto.GetVotesRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.SlugOrID = ctx.Param("slug_or_id")
+	if request.Limit < -1 || request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	response, err := c.registry.ThreadService.GetVotes(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return respondPage(ctx, response.Code, response.Value, response.NextCursor)
+}
+
+func (c *ThreadController) DeleteVote(ctx echo.Context) error {
+	request := &dto.DeleteVoteRequest{SlugOrID: ctx.Param("slug_or_id"), Nickname: ctx.Param("nickname")}
+	response, err := c.registry.ThreadService.DeleteVote(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
 func (c *ThreadController) GetDetails(ctx echo.Context) error {
 	slugOrID := ctx.Param("slug_or_id")
-	response, err := c.registry.ThreadService.GetDetails(context.Background(), slugOrID)
+	response, err := c.registry.ThreadService.GetDetails(ctx.Request().Context(), slugOrID)
 	if err != nil {
 		return err
 	}
@@ -59,7 +84,21 @@ func (c *ThreadController) UpdateForumThread(ctx echo.Context) error {
 		return err
 	}
 	slugOrID := ctx.Param("slug_or_id")
-	response, err := c.registry.ThreadService.UpdateThread(context.Background(), slugOrID, request)
+	response, err := c.registry.ThreadService.UpdateThread(ctx.Request().Context(), slugOrID, request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *ThreadController) UpdateThreadState(ctx echo.Context) error {
+	request := &dto.UpdateThreadStateRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	slugOrID := ctx.Param("slug_or_id")
+	response, err := c.registry.ThreadService.UpdateThreadState(ctx.Request().Context(), slugOrID, request)
 	if err != nil {
 		return err
 	}
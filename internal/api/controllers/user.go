@@ -3,7 +3,6 @@ package controllers
 import (
 	"SYBD/internal/model/dto"
 	"SYBD/internal/service"
-	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -20,7 +19,7 @@ func (c *UserController) CreateUser(ctx echo.Context) error {
 	}
 	request.Nickname = ctx.Param("nickname")
 	//c.log.Infof("request nick: %s", request.Nickname)
-	response, err := c.registry.UserService.CreateUser(context.Background(), request)
+	response, err := c.registry.UserService.CreateUser(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -36,7 +35,7 @@ func (c *UserController) GetProfile(ctx echo.Context) error {
 	request.Nickname = ctx.Param("nickname")
 	//c.log.Infof("request nick: %s", request.Nickname)
 
-	response, err := c.registry.UserService.GetProfile(context.Background(), request)
+	response, err := c.registry.UserService.GetProfile(ctx.Request().Context(), request)
 	if err != nil {
 		//c.log.Infof("err: %s", err)
 		return err
@@ -53,7 +52,7 @@ func (c *UserController) UpdateProfile(ctx echo.Context) error {
 	}
 	request.Nickname = ctx.Param("nickname")
 	//c.log.Infof("request nick: %s", request.Nickname)
-	response, err := c.registry.UserService.UpdateProfile(context.Background(), request)
+	response, err := c.registry.UserService.UpdateProfile(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
@@ -61,6 +60,36 @@ func (c *UserController) UpdateProfile(ctx echo.Context) error {
 	return ctx.JSON(response.Code, response.Value)
 }
 
+func (c *UserController) DeleteUser(ctx echo.Context) error {
+	request := &dto.DeleteUserRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.UserService.DeleteUser(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *UserController) SetRole(ctx echo.Context) error {
+	request := &dto.SetRoleRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Nickname = ctx.Param("nickname")
+
+	response, err := c.registry.UserService.SetRole(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response.Code, response.Value)
+}
+
 func NewUserController(log *logrus.Entry, registry *service.Registry) *UserController {
 	return &UserController{log: log, registry: registry}
 }
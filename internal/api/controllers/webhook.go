@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/dto"
+	"SYBD/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+type WebhookController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *WebhookController) CreateWebhook(ctx echo.Context) error {
+	request := &dto.CreateWebhookRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	request.Slug = ctx.Param("slug")
+
+	response, err := c.registry.WebhookService.CreateWebhook(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *WebhookController) GetWebhooks(ctx echo.Context) error {
+	request := &dto.GetForumRequest{Slug: ctx.Param("slug")}
+
+	response, err := c.registry.WebhookService.GetWebhooks(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx echo.Context) error {
+	id, err := webhookID(ctx)
+	if err != nil {
+		return err
+	}
+	request := &dto.WebhookRequest{Slug: ctx.Param("slug"), ID: id}
+
+	response, err := c.registry.WebhookService.DeleteWebhook(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func (c *WebhookController) GetDeliveries(ctx echo.Context) error {
+	request := &dto.GetDeliveriesRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return err
+	}
+	id, err := webhookID(ctx)
+	if err != nil {
+		return err
+	}
+	request.Slug = ctx.Param("slug")
+	request.ID = id
+
+	response, err := c.registry.WebhookService.GetDeliveries(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return respondPage(ctx, response.Code, response.Value, response.NextCursor)
+}
+
+func (c *WebhookController) Redeliver(ctx echo.Context) error {
+	id, err := webhookID(ctx)
+	if err != nil {
+		return err
+	}
+	delivery, err := strconv.ParseInt(ctx.Param("delivery"), 10, 64)
+	if err != nil {
+		return constants.NewValidationError("Invalid delivery id: %s", ctx.Param("delivery"))
+	}
+	request := &dto.RedeliverRequest{Slug: ctx.Param("slug"), ID: id, Delivery: delivery}
+
+	response, err := c.registry.WebhookService.Redeliver(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(response.Code, response.Value)
+}
+
+func webhookID(ctx echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return 0, constants.NewValidationError("Invalid webhook id: %s", ctx.Param("id"))
+	}
+	return id, nil
+}
+
+func NewWebhookController(log *logrus.Entry, registry *service.Registry) *WebhookController {
+	return &WebhookController{log: log, registry: registry}
+}
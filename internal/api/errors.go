@@ -0,0 +1,91 @@
+package api
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/dto"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgExclusionViolation  = "23P01"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgInvalidText         = "22P02"
+	pgInvalidDatetime     = "22007"
+	pgDatetimeOverflow    = "22008"
+	pgNumericOutOfRange   = "22003"
+	pgStringTruncation    = "22001"
+)
+
+// NewHTTPErrorHandler renders every error returned by a handler as dto.ErrorResponse.
+func NewHTTPErrorHandler(log *logrus.Entry) echo.HTTPErrorHandler {
+	return func(err error, ctx echo.Context) {
+		if ctx.Response().Committed {
+			return
+		}
+
+		code, message := resolveError(err)
+		if code >= http.StatusInternalServerError {
+			log.Errorf("%s %s: %s", ctx.Request().Method, ctx.Request().URL.Path, err)
+			message = http.StatusText(code)
+		}
+
+		if ctx.Request().Method == http.MethodHead {
+			err = ctx.NoContent(code)
+		} else {
+			err = ctx.JSON(code, dto.ErrorResponse{Message: message, Code: code, Errors: itemErrors(err)})
+		}
+		if err != nil {
+			log.Errorf("failed to send error response: %s", err)
+		}
+	}
+}
+
+func itemErrors(err error) []dto.ItemError {
+	var batchErr *constants.BatchError
+	if !errors.As(err, &batchErr) {
+		return nil
+	}
+
+	items := make([]dto.ItemError, 0, len(batchErr.Items))
+	for _, item := range batchErr.Items {
+		items = append(items, dto.ItemError{Index: item.Index, Message: item.Err.Error(), Code: item.Err.Code()})
+	}
+	return items
+}
+
+func resolveError(err error) (int, string) {
+	var codedErr *constants.CodedError
+	var httpErr *echo.HTTPError
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &codedErr):
+		return codedErr.Code(), err.Error()
+	case errors.As(err, &httpErr):
+		return httpErr.Code, fmt.Sprint(httpErr.Message)
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound, constants.ErrDBNotFound.Error()
+	case errors.As(err, &pgErr):
+		switch pgErr.Code {
+		case pgUniqueViolation, pgExclusionViolation:
+			return http.StatusConflict, pgErr.Message
+		case pgForeignKeyViolation:
+			return http.StatusNotFound, pgErr.Message
+		case pgNotNullViolation, pgCheckViolation, pgInvalidText, pgInvalidDatetime,
+			pgDatetimeOverflow, pgNumericOutOfRange, pgStringTruncation:
+			return http.StatusBadRequest, pgErr.Message
+		}
+	}
+
+	return http.StatusInternalServerError, err.Error()
+}
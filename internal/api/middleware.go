@@ -0,0 +1,135 @@
+package api
+
+import (
+	"SYBD/internal/auth"
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/ratelimit"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// authenticate resolves the caller from the bearer token of the request and
+// stores it in the request context. Requests without a token are anonymous;
+// a token which fails verification, or whose user is gone, is rejected. The
+// admin token, when configured, grants the admin role on top of that.
+func authenticate(signer *auth.Signer, users db.UserRepository, legacy bool, adminToken string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			caller := auth.Caller{Legacy: legacy}
+
+			if given := ctx.Request().Header.Get(adminTokenHeader); given != "" {
+				if adminToken == "" || subtle.ConstantTimeCompare([]byte(given), []byte(adminToken)) != 1 {
+					return constants.NewUnauthorizedError("Invalid admin token")
+				}
+				caller.Admin = true
+			}
+
+			if header := ctx.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+				token := strings.TrimPrefix(header, "Bearer ")
+				if token == header {
+					return constants.NewUnauthorizedError("Unsupported authorization scheme")
+				}
+				claims, err := signer.Verify(token, time.Now())
+				if err != nil {
+					return err
+				}
+				id, err := users.GetUserID(ctx.Request().Context(), claims.Subject)
+				if err != nil && !errors.Is(err, constants.ErrDBNotFound) {
+					return err
+				}
+				if id != claims.UserID {
+					return constants.NewUnauthorizedError("Invalid or expired token")
+				}
+				caller.Nickname = claims.Subject
+			}
+
+			request := ctx.Request()
+			ctx.SetRequest(request.WithContext(auth.WithCaller(request.Context(), caller)))
+			return next(ctx)
+		}
+	}
+}
+
+// rateLimit rejects requests over the budget of their route with 429 and a
+// Retry-After header. It runs after authenticate; admins are not limited.
+func rateLimit(limiter *ratelimit.Limiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			method := ctx.Request().Method
+			rule := limiter.Rule(method, ctx.Path())
+			caller := auth.CallerFrom(ctx.Request().Context())
+			if rule == nil || caller.Admin {
+				return next(ctx)
+			}
+
+			cost := 1
+			if rule.PerItem {
+				var err error
+				if cost, err = countItems(ctx.Request()); err != nil {
+					return err
+				}
+			}
+
+			ok, wait := limiter.Allow(method+" "+ctx.Path(), rule, caller.Nickname, ctx.RealIP(), cost, time.Now())
+			if !ok {
+				seconds := int(math.Ceil(wait.Seconds()))
+				ctx.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+				return constants.NewTooManyRequestsError("Rate limit exceeded, retry in %d seconds", seconds)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// countItems returns the number of elements of a JSON array body, 1 for any
+// other body, and leaves the body to be read again.
+func countItems(request *http.Request) (int, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return 0, err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil || len(items) == 0 {
+		return 1, nil
+	}
+	return len(items), nil
+}
+
+// readYourWrites tags the request with the session of its caller, the user
+// or else the client address. Once a session has written successfully its
+// reads stay on the primary, so replicas never hide its own writes.
+func readYourWrites(router *db.Router) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			session := "ip:" + ctx.RealIP()
+			if caller := auth.CallerFrom(ctx.Request().Context()); caller.Nickname != "" {
+				session = "user:" + strings.ToLower(caller.Nickname)
+			}
+
+			request := ctx.Request()
+			sessionCtx := db.WithSession(request.Context(), session)
+			ctx.SetRequest(request.WithContext(sessionCtx))
+
+			err := next(ctx)
+			if method := request.Method; method != http.MethodGet && method != http.MethodHead &&
+				err == nil && ctx.Response().Status < http.StatusBadRequest {
+				router.Wrote(sessionCtx)
+			}
+			return err
+		}
+	}
+}
@@ -2,12 +2,16 @@ package api
 
 import (
 	"SYBD/internal/api/controllers"
+	"SYBD/internal/auth"
 	"SYBD/internal/db"
+	"SYBD/internal/ratelimit"
 	"SYBD/internal/service"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/spf13/viper"
+	"net"
 
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -15,6 +19,8 @@ import (
 type APIService struct {
 	log    *logrus.Entry
 	router *echo.Echo
+	// stop ends the background workers, closing open event streams.
+	stop context.CancelFunc
 }
 
 func (svc *APIService) Serve() {
@@ -24,57 +30,169 @@ func (svc *APIService) Serve() {
 }
 
 func (svc *APIService) Shutdown(ctx context.Context) error {
+	svc.stop()
 	if err := svc.router.Shutdown(ctx); err != nil {
 		svc.log.Fatal(err)
 	}
 	return nil
 }
 
-func NewAPIService(log *logrus.Entry, db_ *pgxpool.Pool) (*APIService, error) {
+func NewAPIService(log *logrus.Entry, repository *db.Repository) (*APIService, error) {
 	svc := &APIService{
 		log:    log,
 		router: echo.New(),
 	}
 
+	svc.router.HTTPErrorHandler = NewHTTPErrorHandler(log)
+
+	extractor, err := ipExtractor(viper.GetStringSlice("service.trusted_proxies"))
+	if err != nil {
+		return nil, err
+	}
+	svc.router.IPExtractor = extractor
+
 	//svc.router.Validator = NewValidator()
 	//svc.router.Binder = NewBinder()
 
-	repository, err := db.NewRepository(db_)
+	signer, err := auth.NewSigner(viper.GetStringMapString("auth.keys"),
+		viper.GetString("auth.active_key"),
+		viper.GetDuration("auth.token_ttl"))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	legacy := viper.GetBool("auth.legacy")
+	if legacy {
+		log.Warn("auth.legacy is enabled: anonymous requests may act as any user")
+	}
+	if !signer.Enabled() {
+		if !legacy {
+			return nil, errors.New("auth.keys and auth.active_key must be set unless auth.legacy is enabled")
+		}
+		log.Warn("auth.keys are not configured: token login is disabled")
+	}
+
+	var limits ratelimit.Config
+	if err := viper.UnmarshalKey("ratelimit", &limits); err != nil {
+		return nil, err
+	}
+	limiter := ratelimit.NewLimiter(limits)
+
+	workers, stop := context.WithCancel(context.Background())
+	svc.stop = stop
+
+	threads := service.NewThreadResolver(repository, viper.GetDuration("cache.thread_ttl"))
+	streams := service.NewStreamService(log, repository, threads, viper.GetDuration("stream.retention"))
+	go streams.Run(workers)
+
+	var webhooks service.WebhookConfig
+	if err := viper.UnmarshalKey("webhook", &webhooks); err != nil {
+		return nil, err
 	}
 
-	registry := service.NewRegistry(log, repository)
+	registry := service.NewRegistry(log, repository, signer, streams, webhooks, threads)
+	go registry.WebhookService.Run(workers)
 
+	authCtrl := controllers.NewAuthController(log, registry)
 	userCtrl := controllers.NewUserController(log, registry)
 	forumCtrl := controllers.NewForumController(log, registry)
 	threadCtrl := controllers.NewThreadController(log, registry)
 	postCtrl := controllers.NewPostController(log, registry)
-	serviceCtrl := controllers.NewServiceController(log, repository)
+	searchCtrl := controllers.NewSearchController(log, registry)
+	serviceCtrl := controllers.NewServiceController(log, registry)
+	streamCtrl := controllers.NewStreamController(log, registry)
+	webhookCtrl := controllers.NewWebhookController(log, registry)
+	notificationCtrl := controllers.NewNotificationController(log, registry)
+	subscriptionCtrl := controllers.NewSubscriptionController(log, registry)
+
+	middleware := []echo.MiddlewareFunc{
+		authenticate(signer, repository.UserRepo, legacy, viper.GetString("service.admin_token")),
+		rateLimit(limiter),
+	}
+	if repository.Router != nil {
+		middleware = append(middleware, readYourWrites(repository.Router))
+	}
+	api := svc.router.Group("/api", middleware...)
 
-	api := svc.router.Group("/api")
+	api.POST("/auth/login", authCtrl.Login)
 
 	api.POST("/user/:nickname/create", userCtrl.CreateUser)
+	api.POST("/user/:nickname/password", authCtrl.SetPassword)
 	api.GET("/user/:nickname/profile", userCtrl.GetProfile)
 	api.POST("/user/:nickname/profile", userCtrl.UpdateProfile)
+	api.DELETE("/user/:nickname", userCtrl.DeleteUser)
+	api.POST("/user/:nickname/role", userCtrl.SetRole)
+	api.GET("/user/:nickname/notifications", notificationCtrl.GetNotifications)
+	api.POST("/user/:nickname/notifications/read", notificationCtrl.MarkRead)
+	api.GET("/user/:nickname/subscriptions", subscriptionCtrl.GetSubscriptions)
+	api.PUT("/user/:nickname/subscriptions/thread/:slug_or_id", subscriptionCtrl.SubscribeThread)
+	api.DELETE("/user/:nickname/subscriptions/thread/:slug_or_id", subscriptionCtrl.UnsubscribeThread)
+	api.PUT("/user/:nickname/subscriptions/forum/:slug", subscriptionCtrl.SubscribeForum)
+	api.DELETE("/user/:nickname/subscriptions/forum/:slug", subscriptionCtrl.UnsubscribeForum)
+	api.GET("/user/:nickname/feed", subscriptionCtrl.GetFeed)
 
 	api.POST("/forum/create", forumCtrl.CreateForum)
 	api.GET("/forum/:slug/details", forumCtrl.GetForum)
+	api.POST("/forum/:slug/details", forumCtrl.UpdateForum)
+	api.DELETE("/forum/:slug", forumCtrl.DeleteForum)
+	api.GET("/forum/:slug/moderators", forumCtrl.GetModerators)
+	api.PUT("/forum/:slug/moderators/:nickname", forumCtrl.AddModerator)
+	api.DELETE("/forum/:slug/moderators/:nickname", forumCtrl.RemoveModerator)
 	api.GET("/forum/:slug/threads", forumCtrl.GetForumThreads)
 	api.POST("/forum/:slug/create", threadCtrl.CreateThread)
 	api.GET("/forum/:slug/users", forumCtrl.GetUsers)
+	api.GET("/forum/:slug/stats", forumCtrl.GetStats)
+	api.GET("/forum/:slug/events", streamCtrl.ForumEvents)
+	api.GET("/forum/:slug/events/ws", streamCtrl.ForumWebSocket)
+	api.POST("/forum/:slug/webhooks", webhookCtrl.CreateWebhook)
+	api.GET("/forum/:slug/webhooks", webhookCtrl.GetWebhooks)
+	api.DELETE("/forum/:slug/webhooks/:id", webhookCtrl.DeleteWebhook)
+	api.GET("/forum/:slug/webhooks/:id/deliveries", webhookCtrl.GetDeliveries)
+	api.POST("/forum/:slug/webhooks/:id/deliveries/:delivery/redeliver", webhookCtrl.Redeliver)
 
 	api.POST("/thread/:slug_or_id/create", postCtrl.CreatePost)
 	api.POST("/thread/:slug_or_id/vote", threadCtrl.UpdateVote)
+	api.GET("/thread/:slug_or_id/votes", threadCtrl.GetVotes)
+	api.DELETE("/thread/:slug_or_id/votes/:nickname", threadCtrl.DeleteVote)
 	api.GET("/thread/:slug_or_id/details", threadCtrl.GetDetails)
 	api.GET("/thread/:slug_or_id/posts", postCtrl.GetPost)
 	api.POST("/thread/:slug_or_id/details", threadCtrl.UpdateForumThread)
+	api.POST("/thread/:slug_or_id/state", threadCtrl.UpdateThreadState)
+	api.GET("/thread/:slug_or_id/events", streamCtrl.ThreadEvents)
+	api.GET("/thread/:slug_or_id/events/ws", streamCtrl.ThreadWebSocket)
 
 	api.GET("/post/:id/details", postCtrl.GetPostDetails)
 	api.POST("/post/:id/details", postCtrl.UpdatePost)
+	api.POST("/post/:id/vote", postCtrl.UpdateVote)
+	api.GET("/post/:id/history", postCtrl.GetPostHistory)
+	api.POST("/post/:id/rollback", postCtrl.RollbackPost)
+	api.DELETE("/post/:id", postCtrl.DeletePost)
+	api.POST("/post/:id/restore", postCtrl.RestorePost)
+	api.DELETE("/post/:id/tree", postCtrl.DeletePostTree)
+
+	api.GET("/search", searchCtrl.Search)
 
 	api.GET("/service/status", serviceCtrl.Status)
 	api.POST("/service/clear", serviceCtrl.Delete)
+	api.GET("/service/metrics", serviceCtrl.Metrics)
 
 	return svc, nil
 }
+
+// ipExtractor returns how the client address of a request is found. Without
+// trusted proxies it is the peer of the connection; otherwise X-Forwarded-For
+// is followed back through the proxies in the given CIDR ranges only.
+func ipExtractor(proxies []string) (echo.IPExtractor, error) {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"SYBD/internal/constants"
+	"context"
+	"strings"
+)
+
+type callerKey struct{}
+
+// Caller is who performs a request. Nickname is empty for anonymous requests;
+// Legacy allows them to name the acting user in the request body as before.
+// Admin is set for requests carrying the configured admin token.
+type Caller struct {
+	Nickname string
+	Legacy   bool
+	Admin    bool
+}
+
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+func CallerFrom(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// Actor resolves the user a write is performed as. Authenticated callers act
+// as themselves and may only repeat their own nickname in claimed; anonymous
+// callers are refused unless the legacy mode lets them use claimed.
+func Actor(ctx context.Context, claimed string) (string, error) {
+	caller := CallerFrom(ctx)
+	switch {
+	case caller.Nickname != "":
+		if claimed != "" && !strings.EqualFold(claimed, caller.Nickname) {
+			return "", constants.NewForbiddenError("Can't act as %s while signed in as %s", claimed, caller.Nickname)
+		}
+		return caller.Nickname, nil
+	case caller.Legacy:
+		return claimed, nil
+	}
+	return "", constants.NewUnauthorizedError("Authentication required")
+}
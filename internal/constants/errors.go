@@ -2,13 +2,17 @@ package constants
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 )
 
 // CodedError is an error wrapper which wraps errors with http status codes.
+// The kind it unwraps to allows callers to match any error of a category
+// with errors.Is, e.g. errors.Is(err, ErrNotFound).
 type CodedError struct {
 	err  error
 	code int
+	kind error
 }
 
 func (ce *CodedError) Error() string {
@@ -19,19 +23,81 @@ func (ce *CodedError) Code() int {
 	return ce.code
 }
 
+func (ce *CodedError) Unwrap() error {
+	return ce.kind
+}
+
 func CreateNewError(s string, code int) *CodedError {
-	return &CodedError{errors.New(s), code}
+	return &CodedError{err: errors.New(s), code: code}
+}
+
+// Error kinds returned by services.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+func NewNotFoundError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusNotFound, kind: ErrNotFound}
+}
+
+func NewConflictError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusConflict, kind: ErrConflict}
+}
+
+func NewValidationError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusBadRequest, kind: ErrValidation}
+}
+
+func NewUnauthorizedError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusUnauthorized, kind: ErrUnauthorized}
+}
+
+func NewTooManyRequestsError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusTooManyRequests, kind: ErrRateLimited}
+}
+
+func NewForbiddenError(format string, a ...interface{}) *CodedError {
+	return &CodedError{err: fmt.Errorf(format, a...), code: http.StatusForbidden, kind: ErrForbidden}
+}
+
+// ItemError is the error of one item of a batch request.
+type ItemError struct {
+	Index int
+	Err   *CodedError
+}
+
+// BatchError lists every failed item of a batch request. It unwraps to the
+// error of the first item, so it is answered with the code of that one.
+type BatchError struct {
+	Items []ItemError
+}
+
+func NewBatchError(items []ItemError) *BatchError {
+	return &BatchError{Items: items}
+}
+
+func (be *BatchError) Error() string {
+	return fmt.Sprintf("item %d: %s", be.Items[0].Index, be.Items[0].Err)
+}
+
+func (be *BatchError) Unwrap() error {
+	return be.Items[0].Err
 }
 
 var (
 	// Bad Request
-	ErrBindRequest     = &CodedError{errors.New("failed to bind request"), http.StatusBadRequest}
-	ErrValidateRequest = &CodedError{errors.New("failed to validate request"), http.StatusBadRequest}
-	ErrDBNotFound      = &CodedError{errors.New("not found in the database"), http.StatusBadRequest}
-	ErrBadJson         = &CodedError{errors.New("bad json request"), http.StatusBadRequest}
+	ErrBindRequest     = NewValidationError("failed to bind request")
+	ErrValidateRequest = NewValidationError("failed to validate request")
+	ErrDBNotFound      = NewNotFoundError("not found in the database")
+	ErrBadJson         = NewValidationError("bad json request")
 
 	// User
-	ErrUserAlreadyExists = &CodedError{errors.New("user with this nickname or email already exists"), http.StatusConflict}
+	ErrUserAlreadyExists = NewConflictError("user with this nickname or email already exists")
 )
 
 var (
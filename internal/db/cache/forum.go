@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"context"
+	"strings"
+)
+
+type forumRepository struct {
+	db.ForumRepository
+	c *caches
+}
+
+func (repo *forumRepository) GetForumBySlug(ctx context.Context, slug string) (*core.Forum, error) {
+	if cached, ok := repo.c.forums.get(strings.ToLower(slug)); ok {
+		forum := cached.(core.Forum)
+		return &forum, nil
+	}
+
+	forum, err := repo.ForumRepository.GetForumBySlug(ctx, slug)
+	if err != nil {
+		return forum, err
+	}
+	repo.c.forums.add(strings.ToLower(slug), *forum)
+	return forum, nil
+}
+
+func (repo *forumRepository) UpdateForum(ctx context.Context, slug string, title string, description string, user string, voteMin int64, voteMax int64) (*core.Forum, error) {
+	forum, err := repo.ForumRepository.UpdateForum(ctx, slug, title, description, user, voteMin, voteMax)
+	repo.c.forgetForum(slug)
+	return forum, err
+}
+
+func (repo *forumRepository) DeleteForum(ctx context.Context, slug string, cascade bool) (*core.Forum, error) {
+	forum, err := repo.ForumRepository.DeleteForum(ctx, slug, cascade)
+	repo.c.purge()
+	return forum, err
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Stats counts the hits, misses and evictions of every cache by name, e.g.
+// users_hits. It is published with the other metrics.
+var Stats = expvar.NewMap("cache")
+
+// lru keeps up to size values, dropping the least recently used one first.
+// Values also expire ttl after they were added. A size of 0 disables it.
+type lru struct {
+	name string
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func (c *lru) get(key string) (interface{}, bool) {
+	if c.size <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			Stats.Add(c.name+"_hits", 1)
+			return e.value, true
+		}
+		c.removeElement(el)
+	}
+	Stats.Add(c.name+"_misses", 1)
+	return nil, false
+}
+
+func (c *lru) add(key string, value interface{}) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+		Stats.Add(c.name+"_evictions", 1)
+	}
+}
+
+func (c *lru) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *lru) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
+
+func newLRU(name string, config Config) *lru {
+	return &lru{name: name, size: config.Size, ttl: config.TTL, items: make(map[string]*list.Element), order: list.New()}
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+)
+
+// postRepository keeps the post counters of forums fresh; posts themselves
+// are not cached.
+type postRepository struct {
+	db.PostRepository
+	c *caches
+}
+
+func (repo *postRepository) CreatePost(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
+	created, err := repo.PostRepository.CreatePost(ctx, forum, thread, posts)
+	repo.c.forgetForum(forum)
+	return created, err
+}
+
+func (repo *postRepository) DeletePost(ctx context.Context, id int64) (*core.Post, error) {
+	post, err := repo.PostRepository.DeletePost(ctx, id)
+	if err == nil {
+		repo.c.forgetForum(post.Forum)
+	}
+	return post, err
+}
+
+func (repo *postRepository) RestorePost(ctx context.Context, id int64) (*core.Post, error) {
+	post, err := repo.PostRepository.RestorePost(ctx, id)
+	if err == nil {
+		repo.c.forgetForum(post.Forum)
+	}
+	return post, err
+}
+
+// DeletePostTree drops every forum, the forum of the tree is unknown here.
+func (repo *postRepository) DeletePostTree(ctx context.Context, id int64) (int64, error) {
+	deleted, err := repo.PostRepository.DeletePostTree(ctx, id)
+	repo.c.forums.purge()
+	return deleted, err
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"SYBD/internal/db"
+	"strings"
+	"time"
+)
+
+// Config sizes one cache. Values are dropped TTL after they were read from
+// the repository, and the least recently used ones once Size is exceeded.
+type Config struct {
+	Size int           `mapstructure:"size"`
+	TTL  time.Duration `mapstructure:"ttl"`
+}
+
+type Configs struct {
+	Users  Config `mapstructure:"users"`
+	Forums Config `mapstructure:"forums"`
+}
+
+// caches are shared by the decorators, since writes through one repository
+// change rows cached by another, e.g. new posts the counters of the forum.
+type caches struct {
+	users  *lru
+	forums *lru
+}
+
+func (c *caches) forgetUser(nickname string) {
+	c.users.remove(strings.ToLower(nickname))
+}
+
+func (c *caches) forgetForum(slug string) {
+	c.forums.remove(strings.ToLower(slug))
+}
+
+// purge drops everything, for writes which change more rows than can be
+// told from their arguments.
+func (c *caches) purge() {
+	c.users.purge()
+	c.forums.purge()
+}
+
+// Wrap returns a copy of repository whose user and forum lookups are read
+// through in-process caches. Writes through the returned repository
+// invalidate what they change. Threads are cached by the thread resolver of
+// the services instead, which also knows when a fresh read is needed.
+func Wrap(repository *db.Repository, configs Configs) *db.Repository {
+	c := &caches{
+		users:  newLRU("users", configs.Users),
+		forums: newLRU("forums", configs.Forums),
+	}
+
+	wrapped := *repository
+	wrapped.UserRepo = &userRepository{UserRepository: repository.UserRepo, c: c}
+	wrapped.ForumRepo = &forumRepository{ForumRepository: repository.ForumRepo, c: c}
+	wrapped.PostRepo = &postRepository{PostRepository: repository.PostRepo, c: c}
+	wrapped.ServiceRepo = &serviceRepository{ServiceRepository: repository.ServiceRepo, c: c}
+	return &wrapped
+}
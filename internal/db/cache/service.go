@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"SYBD/internal/db"
+	"context"
+)
+
+type serviceRepository struct {
+	db.ServiceRepository
+	c *caches
+}
+
+func (repo *serviceRepository) Delete(ctx context.Context) error {
+	err := repo.ServiceRepository.Delete(ctx)
+	repo.c.purge()
+	return err
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"context"
+	"strings"
+)
+
+type userRepository struct {
+	db.UserRepository
+	c *caches
+}
+
+func (repo *userRepository) GetUserByNickname(ctx context.Context, nickname string) (*core.User, error) {
+	if cached, ok := repo.c.users.get(strings.ToLower(nickname)); ok {
+		user := cached.(core.User)
+		return &user, nil
+	}
+
+	user, err := repo.UserRepository.GetUserByNickname(ctx, nickname)
+	if err != nil {
+		return user, err
+	}
+	repo.c.users.add(strings.ToLower(nickname), *user)
+	return user, nil
+}
+
+func (repo *userRepository) UpdateUser(ctx context.Context, user *core.User) (*core.User, error) {
+	updated, err := repo.UserRepository.UpdateUser(ctx, user)
+	repo.c.forgetUser(user.Nickname)
+	return updated, err
+}
+
+// DeleteUser purges every cache: the content of the user is re-attributed
+// or removed, which changes threads and the counters of forums.
+func (repo *userRepository) DeleteUser(ctx context.Context, nickname string, mode string, dryRun bool) (*core.UserDeletion, error) {
+	deletion, err := repo.UserRepository.DeleteUser(ctx, nickname, mode, dryRun)
+	if !dryRun {
+		repo.c.purge()
+	}
+	return deletion, err
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"time"
+)
+
+const (
+	// SELECT
+	qGetEvents = "SELECT id, txid, type, forum, thread, post, votes, created FROM \"event\" " +
+		"WHERE (txid, id) > ($1, $2) AND txid < txid_snapshot_xmin(txid_current_snapshot()) " +
+		"AND ($3 = '' OR forum = $3::citext) AND ($4 = 0 OR thread = $4) ORDER BY txid, id LIMIT $5;"
+	qEventWatermark = "SELECT txid_snapshot_xmin(txid_current_snapshot());"
+	qListenEvents   = "LISTEN \"event\";"
+
+	// DELETE
+	qPruneEvents = "DELETE FROM \"event\" WHERE created < $1;"
+)
+
+type EventRepository interface {
+	// GetEvents returns up to limit events following after, of forum and
+	// thread when they are set. Events of transactions which may still be
+	// followed by events of running ones are held back.
+	GetEvents(ctx context.Context, after core.EventPosition, forum string, thread int64, limit int64) ([]*core.Event, error)
+	// EventWatermark returns the position every event not read yet follows.
+	EventWatermark(ctx context.Context) (core.EventPosition, error)
+	// Listen calls notify with the id of every new event until ctx is done
+	// or the connection fails.
+	Listen(ctx context.Context, notify func(id int64)) error
+	PruneEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type eventRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *eventRepositoryImpl) GetEvents(ctx context.Context, after core.EventPosition, forum string, thread int64, limit int64) ([]*core.Event, error) {
+	return repo.getEvents(ctx, qGetEvents, after.TxID, after.ID, forum, thread, limit)
+}
+
+func (repo *eventRepositoryImpl) getEvents(ctx context.Context, query string, args ...interface{}) ([]*core.Event, error) {
+	rows, err := repo.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*core.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (repo *eventRepositoryImpl) EventWatermark(ctx context.Context) (core.EventPosition, error) {
+	var xmin int64
+	err := repo.db.QueryRow(ctx, qEventWatermark).Scan(&xmin)
+	return core.EventPosition{TxID: xmin}, err
+}
+
+func (repo *eventRepositoryImpl) Listen(ctx context.Context, notify func(id int64)) error {
+	conn, err := repo.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, qListenEvents); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// The connection still listens, it must not go back to the pool.
+			_ = conn.Conn().Close(context.Background())
+			return err
+		}
+		if id, err := strconv.ParseInt(n.Payload, 10, 64); err == nil {
+			notify(id)
+		}
+	}
+}
+
+func (repo *eventRepositoryImpl) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	res, err := repo.db.Exec(ctx, qPruneEvents, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func scanEvent(row pgx.Row) (*core.Event, error) {
+	event := &core.Event{}
+	var post *int64
+	if err := row.Scan(&event.ID, &event.TxID, &event.Type, &event.Forum, &event.Thread, &post, &event.Votes, &event.Created); err != nil {
+		return nil, err
+	}
+	if post != nil {
+		event.PostID = *post
+	}
+	event.Cursor = event.Position().String()
+	return event, nil
+}
+
+func NewEventRepository(db *pgxpool.Pool) *eventRepositoryImpl {
+	return &eventRepositoryImpl{db: db}
+}
@@ -1,31 +1,57 @@
 package db
 
 import (
+	"SYBD/internal/constants"
 	"SYBD/internal/model/core"
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v4/pgxpool"
-
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 const (
 	// INSERT
-	qCreateForum = `INSERT INTO "forum" (title, "user", slug) VALUES ($1, $2, $3);`
+	qCreateForum = `INSERT INTO "forum" (title, "user", slug, description, vote_min, vote_max) VALUES ($1, $2, $3, $4, $5, $6);`
+
+	// UPDATE
+	qUpdateForum = `UPDATE "forum" SET title = $2, description = $3, "user" = $4, vote_min = $5, vote_max = $6 WHERE slug = $1 RETURNING title, "user", slug, posts, threads, description, vote_min, vote_max;`
 
 	// SELECT
-	qGetForumBySlug = `SELECT title, "user", slug, posts, threads FROM "forum" WHERE slug = $1;`
+	qGetForumBySlug       = `SELECT title, "user", slug, posts, threads, description, vote_min, vote_max FROM "forum" WHERE slug = $1;`
+	qGetForumBySlugLocked = `SELECT title, "user", slug, posts, threads, description, vote_min, vote_max FROM "forum" WHERE slug = $1 FOR UPDATE;`
+	qForumHasThreads      = `SELECT EXISTS(SELECT 1 FROM "thread" WHERE forum = $1);`
+	qGetModerators        = `SELECT u.nickname, u.fullname, u.about, u.email FROM "forum_moderator" m JOIN "user" u ON u.nickname = m.nickname WHERE m.forum = $1 ORDER BY u.nickname;`
+	qIsModerator          = `SELECT EXISTS(SELECT 1 FROM "forum_moderator" WHERE forum = $1 AND nickname = $2);`
+
+	// moderators
+	qAddModerator    = `INSERT INTO "forum_moderator" (forum, nickname) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+	qRemoveModerator = `DELETE FROM "forum_moderator" WHERE forum = $1 AND nickname = $2;`
+
+	// DELETE
+	qDeleteForumVotes   = `DELETE FROM "vote" WHERE thread IN (SELECT id FROM "thread" WHERE forum = $1);`
+	qDeleteForumPosts   = `DELETE FROM "post" WHERE forum = $1;`
+	qDeleteForumThreads = `DELETE FROM "thread" WHERE forum = $1;`
+	qDeleteForumUsers   = `DELETE FROM "forum_user" WHERE forum = $1;`
+	qDeleteForum        = `DELETE FROM "forum" WHERE slug = $1;`
 )
 
 type ForumRepository interface {
 	CreateForum(ctx context.Context, forum *core.Forum) error
 	GetForumBySlug(ctx context.Context, slug string) (*core.Forum, error)
-	GetUsersFromForum(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.User, error)
-	GetThreadsFromForum(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.Thread, error)
+	UpdateForum(ctx context.Context, slug string, title string, description string, user string, voteMin int64, voteMax int64) (*core.Forum, error)
+	DeleteForum(ctx context.Context, slug string, cascade bool) (*core.Forum, error)
+	GetModerators(ctx context.Context, slug string) ([]*core.User, error)
+	IsModerator(ctx context.Context, slug string, nickname string) (bool, error)
+	AddModerator(ctx context.Context, slug string, nickname string) error
+	RemoveModerator(ctx context.Context, slug string, nickname string) (bool, error)
+	GetUsersFromForum(ctx context.Context, slug string, limit int64, after *core.Cursor, desc bool) ([]*core.User, error)
+	// GetThreadsFromForum orders threads by the key of sort and then by id.
+	GetThreadsFromForum(ctx context.Context, slug string, sort string, limit int64, after *core.Cursor, desc bool) ([]*core.Thread, error)
 }
 
 type forumRepositoryImpl struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	router *Router
 }
 
 func (repo *forumRepositoryImpl) CreateForum(ctx context.Context, forum *core.Forum) error {
@@ -33,26 +59,139 @@ func (repo *forumRepositoryImpl) CreateForum(ctx context.Context, forum *core.Fo
 		qCreateForum,
 		&forum.Title,
 		&forum.User,
-		&forum.Slug)
+		&forum.Slug,
+		&forum.Description,
+		&forum.VoteMin,
+		&forum.VoteMax)
 	return err
 }
 
 func (repo *forumRepositoryImpl) GetForumBySlug(ctx context.Context, slug string) (*core.Forum, error) {
-	forum := &core.Forum{}
-	err := repo.db.QueryRow(ctx,
+	forum, err := scanForum(repo.db.QueryRow(ctx,
 		qGetForumBySlug,
-		slug).
-		Scan(&forum.Title,
-			&forum.User,
-			&forum.Slug,
-			&forum.Posts,
-			&forum.Threads)
+		slug))
 	return forum, wrapErr(err)
 }
 
-func (repo *forumRepositoryImpl) GetUsersFromForum(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.User, error) {
-	query := constructGetForumUsersQuery(limit, since, desc)
-	rows, err := repo.db.Query(ctx, query, slug)
+func (repo *forumRepositoryImpl) UpdateForum(ctx context.Context, slug string, title string, description string, user string, voteMin int64, voteMax int64) (*core.Forum, error) {
+	forum, err := scanForum(repo.db.QueryRow(ctx,
+		qUpdateForum,
+		slug,
+		title,
+		description,
+		user,
+		voteMin,
+		voteMax))
+	return forum, wrapErr(err)
+}
+
+// DeleteForum removes the forum. Unless cascade is set a forum which still has
+// threads is left untouched; otherwise its threads, posts, votes and members go
+// with it in the same transaction.
+func (repo *forumRepositoryImpl) DeleteForum(ctx context.Context, slug string, cascade bool) (*core.Forum, error) {
+	var forum *core.Forum
+	err := repo.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		if forum, err = scanForum(tx.QueryRow(ctx, qGetForumBySlugLocked, slug)); err != nil {
+			return err
+		}
+
+		if !cascade {
+			var hasThreads bool
+			if err := tx.QueryRow(ctx, qForumHasThreads, forum.Slug).Scan(&hasThreads); err != nil {
+				return err
+			}
+			if hasThreads {
+				return constants.NewConflictError("Forum %s is not empty", forum.Slug)
+			}
+		}
+
+		for _, q := range []string{qDeleteForumVotes, qDeleteForumPosts, qDeleteForumThreads, qDeleteForumUsers, qDeleteForum} {
+			if _, err := tx.Exec(ctx, q, forum.Slug); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return forum, nil
+}
+
+func (repo *forumRepositoryImpl) GetModerators(ctx context.Context, slug string) ([]*core.User, error) {
+	rows, err := repo.db.Query(ctx, qGetModerators, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*core.User, 0)
+	for rows.Next() {
+		u := &core.User{}
+		if err := rows.Scan(&u.Nickname, &u.FullName, &u.About, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (repo *forumRepositoryImpl) IsModerator(ctx context.Context, slug string, nickname string) (bool, error) {
+	var ok bool
+	err := repo.db.QueryRow(ctx, qIsModerator, slug, nickname).Scan(&ok)
+	return ok, err
+}
+
+func (repo *forumRepositoryImpl) AddModerator(ctx context.Context, slug string, nickname string) error {
+	_, err := repo.db.Exec(ctx, qAddModerator, slug, nickname)
+	return err
+}
+
+func (repo *forumRepositoryImpl) RemoveModerator(ctx context.Context, slug string, nickname string) (bool, error) {
+	res, err := repo.db.Exec(ctx, qRemoveModerator, slug, nickname)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func scanForum(row pgx.Row) (*core.Forum, error) {
+	forum := &core.Forum{}
+	err := row.Scan(&forum.Title,
+		&forum.User,
+		&forum.Slug,
+		&forum.Posts,
+		&forum.Threads,
+		&forum.Description,
+		&forum.VoteMin,
+		&forum.VoteMax)
+	return forum, err
+}
+
+func (repo *forumRepositoryImpl) GetUsersFromForum(ctx context.Context, slug string, limit int64, after *core.Cursor, desc bool) ([]*core.User, error) {
+	conn := repo.router.Read(ctx)
+	query := "SELECT u.nickname, u.fullname, u.about, u.email FROM \"forum_user\" u WHERE u.forum = $1 "
+	args := []interface{}{slug}
+
+	if after != nil {
+		if desc {
+			query += "AND u.nickname < $2 "
+		} else {
+			query += "AND u.nickname > $2 "
+		}
+		args = append(args, after.Nickname)
+	}
+
+	query += "ORDER BY u.nickname "
+	if desc {
+		query += "DESC "
+	}
+	if limit > 0 {
+		query += fmt.Sprintf("LIMIT %d ", limit)
+	}
+
+	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -71,37 +210,53 @@ func (repo *forumRepositoryImpl) GetUsersFromForum(ctx context.Context, slug str
 }
 
 const (
-	qTemplate = "SELECT t.id, t.title, t.author, t.forum, t.message, t.votes, t.slug, t.created FROM \"thread\" as t LEFT JOIN \"forum\" f ON t.forum = f.slug WHERE f.slug = $1 "
+	qTemplate = "SELECT t.id, t.title, t.author, t.forum, t.message, t.votes, t.slug, t.created, t.state, t.active, t.hot FROM \"thread\" as t LEFT JOIN \"forum\" f ON t.forum = f.slug WHERE f.slug = $1 "
 )
 
-func (repo *forumRepositoryImpl) GetThreadsFromForum(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.Thread, error) {
-	var rows pgx.Rows
-	var err error
+func (repo *forumRepositoryImpl) GetThreadsFromForum(ctx context.Context, slug string, sort string, limit int64, after *core.Cursor, desc bool) ([]*core.Thread, error) {
+	conn := repo.router.Read(ctx)
 	// Create query with conditions
 	query := qTemplate
+	args := []interface{}{slug}
 
-	queryOrderBy := "ORDER BY t.created "
-	if desc {
-		queryOrderBy += "DESC "
-	}
-	if limit > 0 {
-		queryOrderBy += fmt.Sprintf("LIMIT %d ", limit)
+	// Time keys are kept in Created of the cursor, numeric ones in Score.
+	column := "t.created"
+	switch sort {
+	case core.ThreadSortTop:
+		column = "t.votes"
+	case core.ThreadSortActive:
+		column = "t.active"
+	case core.ThreadSortHot:
+		column = "t.hot"
 	}
 
-	if since != "" {
-		querySince := "AND t.created >= $2 "
-		if since != "" && desc {
-			querySince = "AND t.created <= $2 "
-		} else if since != "" && !desc {
-			querySince = "AND t.created >= $2 "
+	// (key, id) is unique, so rows sharing a key are never skipped or repeated.
+	if after != nil {
+		if desc {
+			query += fmt.Sprintf("AND (%s, t.id) < ($2, $3) ", column)
+		} else {
+			query += fmt.Sprintf("AND (%s, t.id) > ($2, $3) ", column)
+		}
+		switch sort {
+		case core.ThreadSortTop:
+			args = append(args, int64(after.Score), after.ID)
+		case core.ThreadSortHot:
+			args = append(args, after.Score, after.ID)
+		default:
+			args = append(args, after.Created, after.ID)
 		}
+	}
 
-		query += querySince + queryOrderBy
-		rows, err = repo.db.Query(ctx, query, slug, since)
+	if desc {
+		query += fmt.Sprintf("ORDER BY %s DESC, t.id DESC ", column)
 	} else {
-		query += queryOrderBy
-		rows, err = repo.db.Query(ctx, query, slug)
+		query += fmt.Sprintf("ORDER BY %s, t.id ", column)
+	}
+	if limit > 0 {
+		query += fmt.Sprintf("LIMIT %d ", limit)
 	}
+
+	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -110,10 +265,7 @@ func (repo *forumRepositoryImpl) GetThreadsFromForum(ctx context.Context, slug s
 	threads := make([]*core.Thread, 0, rows.CommandTag().RowsAffected())
 	for rows.Next() {
 		t := &core.Thread{}
-		if err := rows.Scan(&t.ID, &t.Title,
-			&t.Author, &t.Forum,
-			&t.Message, &t.Votes,
-			&t.Slug, &t.Created); err != nil {
+		if err := rows.Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.State, &t.Active, &t.Hot); err != nil {
 			return nil, err
 		}
 		threads = append(threads, t)
@@ -122,26 +274,6 @@ func (repo *forumRepositoryImpl) GetThreadsFromForum(ctx context.Context, slug s
 	return threads, nil
 }
 
-func NewForumRepository(db *pgxpool.Pool) *forumRepositoryImpl {
-	return &forumRepositoryImpl{db: db}
-}
-
-func constructGetForumUsersQuery(limit int64, since string, desc bool) string {
-	query := "SELECT u.nickname, u.fullname, u.about, u.email from \"forum_user\" u where u.forum = $1 "
-
-	if len(since) > 0 {
-		if desc {
-			query += fmt.Sprintf("and u.nickname < '%s' ", since)
-		} else {
-			query += fmt.Sprintf("and u.nickname > '%s' ", since)
-		}
-	}
-
-	query += "ORDER BY u.nickname "
-	if desc {
-		query += "DESC "
-	}
-	query += fmt.Sprintf("LIMIT %d ", limit)
-
-	return query
+func NewForumRepository(router *Router) *forumRepositoryImpl {
+	return &forumRepositoryImpl{db: router.Primary(), router: router}
 }
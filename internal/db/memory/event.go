@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"time"
+)
+
+type eventRepositoryImpl struct {
+	s *store
+}
+
+// GetEvents holds no event back: events are added under the lock of the
+// store, so ids follow the order in which they become visible.
+func (repo *eventRepositoryImpl) GetEvents(ctx context.Context, after core.EventPosition, forum string, thread int64, limit int64) ([]*core.Event, error) {
+	return repo.getEvents(func(e *core.Event) bool { return e.Position().After(after) }, forum, thread, limit)
+}
+
+func (repo *eventRepositoryImpl) getEvents(follows func(e *core.Event) bool, forum string, thread int64, limit int64) ([]*core.Event, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	events := make([]*core.Event, 0)
+	for _, e := range repo.s.events {
+		if int64(len(events)) == limit {
+			break
+		}
+		if !follows(e) || (forum != "" && key(e.Forum) != key(forum)) || (thread != 0 && e.Thread != thread) {
+			continue
+		}
+		event := *e
+		event.Cursor = event.Position().String()
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (repo *eventRepositoryImpl) EventWatermark(ctx context.Context) (core.EventPosition, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	return core.EventPosition{ID: repo.s.eventSeq}, nil
+}
+
+func (repo *eventRepositoryImpl) Listen(ctx context.Context, notify func(id int64)) error {
+	for {
+		repo.s.mu.RLock()
+		signal := repo.s.eventSignal
+		repo.s.mu.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-signal:
+		}
+
+		repo.s.mu.RLock()
+		id := repo.s.eventSeq
+		repo.s.mu.RUnlock()
+		notify(id)
+	}
+}
+
+func (repo *eventRepositoryImpl) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	kept := repo.s.events[:0]
+	for _, e := range repo.s.events {
+		if !e.Created.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	pruned := int64(len(repo.s.events) - len(kept))
+	repo.s.events = kept
+	return pruned, nil
+}
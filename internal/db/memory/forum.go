@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"time"
+)
+
+var errForumExists = constants.NewConflictError("forum with this slug already exists")
+
+type forumRepositoryImpl struct {
+	s *store
+}
+
+func (repo *forumRepositoryImpl) CreateForum(ctx context.Context, forum *core.Forum) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.forums[key(forum.Slug)]; ok {
+		return errForumExists
+	}
+	repo.s.forums[key(forum.Slug)] = &core.Forum{Title: forum.Title, User: forum.User, Slug: forum.Slug, Description: forum.Description, VoteMin: forum.VoteMin, VoteMax: forum.VoteMax}
+	return nil
+}
+
+func (repo *forumRepositoryImpl) GetForumBySlug(ctx context.Context, slug string) (*core.Forum, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	f, ok := repo.s.forums[key(slug)]
+	if !ok {
+		return &core.Forum{}, constants.ErrDBNotFound
+	}
+	forum := *f
+	return &forum, nil
+}
+
+func (repo *forumRepositoryImpl) UpdateForum(ctx context.Context, slug string, title string, description string, user string, voteMin int64, voteMax int64) (*core.Forum, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	f, ok := repo.s.forums[key(slug)]
+	if !ok {
+		return &core.Forum{}, constants.ErrDBNotFound
+	}
+	f.Title = title
+	f.Description = description
+	f.User = user
+	f.VoteMin = voteMin
+	f.VoteMax = voteMax
+
+	forum := *f
+	return &forum, nil
+}
+
+func (repo *forumRepositoryImpl) DeleteForum(ctx context.Context, slug string, cascade bool) (*core.Forum, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	f, ok := repo.s.forums[key(slug)]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+
+	threads := make(map[int64]bool)
+	for id, t := range repo.s.threads {
+		if key(t.Forum) == key(slug) {
+			threads[id] = true
+		}
+	}
+	if !cascade && len(threads) > 0 {
+		return nil, constants.NewConflictError("Forum %s is not empty", f.Slug)
+	}
+
+	for k := range repo.s.votes {
+		if threads[k.thread] {
+			delete(repo.s.votes, k)
+		}
+	}
+	for _, p := range repo.s.posts {
+		if threads[p.Thread] {
+			repo.s.deletePost(p)
+		}
+	}
+	for id, t := range repo.s.threads {
+		if threads[id] {
+			delete(repo.s.threadSlug, key(t.Slug))
+			delete(repo.s.threads, id)
+		}
+	}
+	for k := range repo.s.subscriptions {
+		if threads[k.thread] || k.forum == key(slug) {
+			delete(repo.s.subscriptions, k)
+		}
+	}
+	delete(repo.s.forumUsers, key(slug))
+	delete(repo.s.moderators, key(slug))
+	for id, w := range repo.s.webhooks {
+		if key(w.Forum) == key(slug) {
+			repo.s.deleteWebhook(id)
+		}
+	}
+	delete(repo.s.forums, key(slug))
+
+	forum := *f
+	return &forum, nil
+}
+
+func (repo *forumRepositoryImpl) GetModerators(ctx context.Context, slug string) ([]*core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	users := make([]*core.User, 0)
+	for nick := range repo.s.moderators[key(slug)] {
+		if u, ok := repo.s.users[nick]; ok {
+			user := *u
+			users = append(users, &user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return key(users[i].Nickname) < key(users[j].Nickname)
+	})
+	return users, nil
+}
+
+func (repo *forumRepositoryImpl) IsModerator(ctx context.Context, slug string, nickname string) (bool, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	_, ok := repo.s.moderators[key(slug)][key(nickname)]
+	return ok, nil
+}
+
+func (repo *forumRepositoryImpl) AddModerator(ctx context.Context, slug string, nickname string) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	// Mirrors the forum_moderator foreign keys.
+	if _, ok := repo.s.forums[key(slug)]; !ok {
+		return constants.ErrDBNotFound
+	}
+	u, ok := repo.s.users[key(nickname)]
+	if !ok {
+		return constants.ErrDBNotFound
+	}
+
+	members, ok := repo.s.moderators[key(slug)]
+	if !ok {
+		members = make(map[string]string)
+		repo.s.moderators[key(slug)] = members
+	}
+	members[key(nickname)] = u.Nickname
+	return nil
+}
+
+func (repo *forumRepositoryImpl) RemoveModerator(ctx context.Context, slug string, nickname string) (bool, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	members := repo.s.moderators[key(slug)]
+	if _, ok := members[key(nickname)]; !ok {
+		return false, nil
+	}
+	delete(members, key(nickname))
+	return true, nil
+}
+
+func (repo *forumRepositoryImpl) GetUsersFromForum(ctx context.Context, slug string, limit int64, after *core.Cursor, desc bool) ([]*core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	users := make([]*core.User, 0)
+	for nick := range repo.s.forumUsers[key(slug)] {
+		if after != nil {
+			if desc && nick >= key(after.Nickname) || !desc && nick <= key(after.Nickname) {
+				continue
+			}
+		}
+		if u, ok := repo.s.users[nick]; ok {
+			user := *u
+			users = append(users, &user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if desc {
+			return key(users[i].Nickname) > key(users[j].Nickname)
+		}
+		return key(users[i].Nickname) < key(users[j].Nickname)
+	})
+
+	if limit > 0 && int64(len(users)) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (repo *forumRepositoryImpl) GetThreadsFromForum(ctx context.Context, slug string, by string, limit int64, after *core.Cursor, desc bool) ([]*core.Thread, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	// position holds the key of the cursor in the field the sort reads.
+	var position *core.Thread
+	if after != nil {
+		position = &core.Thread{ID: after.ID, Created: after.Created, Active: after.Created, Votes: int64(after.Score), Hot: after.Score}
+	}
+
+	threads := make([]*core.Thread, 0)
+	for _, t := range repo.s.threads {
+		if key(t.Forum) != key(slug) {
+			continue
+		}
+		if position != nil {
+			cmp := compareThreads(by, t, position)
+			if desc && cmp >= 0 || !desc && cmp <= 0 {
+				continue
+			}
+		}
+		thread := *t
+		threads = append(threads, &thread)
+	}
+
+	sort.Slice(threads, func(i, j int) bool {
+		cmp := compareThreads(by, threads[i], threads[j])
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	if limit > 0 && int64(len(threads)) > limit {
+		threads = threads[:limit]
+	}
+	return threads, nil
+}
+
+// compareThreads orders threads by (key, id), the key being the one of the
+// thread sort by.
+func compareThreads(by string, a *core.Thread, b *core.Thread) int {
+	cmp := 0
+	switch by {
+	case core.ThreadSortTop:
+		cmp = compareIDs(a.Votes, b.Votes)
+	case core.ThreadSortActive:
+		cmp = compareTimes(a.Active, b.Active)
+	case core.ThreadSortHot:
+		switch {
+		case a.Hot < b.Hot:
+			cmp = -1
+		case a.Hot > b.Hot:
+			cmp = 1
+		}
+	default:
+		cmp = compareTimes(a.Created, b.Created)
+	}
+	if cmp != 0 {
+		return cmp
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"time"
+)
+
+type notificationRepositoryImpl struct {
+	s *store
+}
+
+func (repo *notificationRepositoryImpl) CreateNotifications(ctx context.Context, notifications []*core.Notification) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	for _, n := range notifications {
+		u, ok := repo.s.users[key(n.Nickname)]
+		if !ok || repo.s.hasNotification(u.Nickname, n.PostID, n.Kind) {
+			continue
+		}
+		repo.s.notifySeq++
+		repo.s.notifications[repo.s.notifySeq] = &core.Notification{
+			ID:       repo.s.notifySeq,
+			Nickname: u.Nickname,
+			Kind:     n.Kind,
+			PostID:   n.PostID,
+			Created:  time.Now(),
+		}
+	}
+	return nil
+}
+
+func (repo *notificationRepositoryImpl) GetNotifications(ctx context.Context, nickname string, unread bool, before int64, limit int64) ([]*core.Notification, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	notifications := make([]*core.Notification, 0)
+	for _, n := range repo.s.notifications {
+		if key(n.Nickname) != key(nickname) || (unread && n.Read) || (before != 0 && n.ID >= before) {
+			continue
+		}
+		p, ok := repo.s.posts[n.PostID]
+		if !ok {
+			continue
+		}
+		notification := *n
+		notification.Post = p.view()
+		notifications = append(notifications, &notification)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].ID > notifications[j].ID
+	})
+	if int64(len(notifications)) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (repo *notificationRepositoryImpl) CountUnread(ctx context.Context, nickname string) (int64, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	var count int64
+	for _, n := range repo.s.notifications {
+		if key(n.Nickname) == key(nickname) && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (repo *notificationRepositoryImpl) MarkRead(ctx context.Context, nickname string, ids []int64) (int64, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var marked int64
+	for _, n := range repo.s.notifications {
+		if key(n.Nickname) != key(nickname) || n.Read || (len(ids) > 0 && !wanted[n.ID]) {
+			continue
+		}
+		n.Read = true
+		marked++
+	}
+	return marked, nil
+}
@@ -0,0 +1,467 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type postRepositoryImpl struct {
+	s *store
+}
+
+func (repo *postRepositoryImpl) CreatePost(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	newPosts := make([]*core.Post, 0, len(posts))
+	insertTime := time.Unix(0, time.Now().UnixNano()/1e6*1e6)
+	for _, p := range posts {
+		repo.s.postSeq++
+		record := &post{Post: core.Post{
+			ID:      repo.s.postSeq,
+			Pred:    p.Parent,
+			Author:  p.Author,
+			Message: p.Message,
+			Forum:   forum,
+			Thread:  thread,
+			Created: insertTime,
+		}}
+
+		// Mirrors the update_path trigger: a missing parent yields a root path.
+		if parent, ok := repo.s.posts[p.Parent]; ok {
+			record.path = append(append(make([]int64, 0, len(parent.path)+1), parent.path...), record.ID)
+		} else {
+			record.path = []int64{record.ID}
+		}
+		repo.s.posts[record.ID] = record
+
+		// Mirrors the update_count_posts, forum_user and thread_active triggers.
+		if f, ok := repo.s.forums[key(forum)]; ok {
+			f.Posts++
+		}
+		if t, ok := repo.s.threads[thread]; ok && t.Active.Before(insertTime) {
+			t.Active = insertTime
+		}
+		repo.s.addForumUser(forum, p.Author)
+		repo.s.addEvent(&core.Event{Type: core.EventPostCreated, Forum: forum, Thread: thread, PostID: record.ID})
+
+		created := record.Post
+		newPosts = append(newPosts, &created)
+	}
+
+	return newPosts, nil
+}
+
+func (repo *postRepositoryImpl) GetPostThreads(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	threads := make(map[int64]int64, len(ids))
+	for _, id := range ids {
+		if p, ok := repo.s.posts[id]; ok {
+			threads[id] = p.Thread
+		}
+	}
+	return threads, nil
+}
+
+func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	records := repo.threadPosts(int64(id), func(p *post) bool {
+		if after == nil {
+			return true
+		}
+		cmp := 0
+		if after.Created.IsZero() {
+			cmp = compareIDs(p.ID, after.ID)
+		} else {
+			cmp = compareFlat(p, after.Created, after.ID)
+		}
+		if desc {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	sort.Slice(records, func(i, j int) bool {
+		cmp := compareFlat(records[i], records[j].Created, records[j].ID)
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	// Unlike the NULLIF of the tree and top sorts, LIMIT of the flat query
+	// keeps nothing for limit 0.
+	if limit <= 0 {
+		return []*core.Post{}, nil
+	}
+	return toPosts(records, limit), nil
+}
+
+func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	var afterPath []int64
+	if after != nil {
+		p, ok := repo.s.posts[after.ID]
+		if !ok {
+			return []*core.Post{}, nil
+		}
+		afterPath = p.path
+	}
+
+	records := repo.threadPosts(int64(id), func(p *post) bool {
+		if afterPath == nil {
+			return true
+		}
+		if desc {
+			return comparePath(p.path, afterPath) < 0
+		}
+		return comparePath(p.path, afterPath) > 0
+	})
+
+	sort.Slice(records, func(i, j int) bool {
+		if desc {
+			return comparePath(records[i].path, records[j].path) > 0
+		}
+		return comparePath(records[i].path, records[j].path) < 0
+	})
+
+	return toPosts(records, limit), nil
+}
+
+func (repo *postRepositoryImpl) GetPostPredTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	var afterRoot int64
+	if after != nil {
+		p, ok := repo.s.posts[after.ID]
+		if !ok {
+			return []*core.Post{}, nil
+		}
+		afterRoot = p.path[0]
+	}
+
+	roots := repo.threadPosts(int64(id), func(p *post) bool {
+		if p.Pred != 0 {
+			return false
+		}
+		if after == nil {
+			return true
+		}
+		if desc {
+			return p.path[0] < afterRoot
+		}
+		return p.path[0] > afterRoot
+	})
+	sort.Slice(roots, func(i, j int) bool {
+		if desc {
+			return roots[i].ID > roots[j].ID
+		}
+		return roots[i].ID < roots[j].ID
+	})
+	// LIMIT $2 keeps no roots for limit 0, like the flat query.
+	if limit < 0 {
+		limit = 0
+	}
+	if int64(len(roots)) > limit {
+		roots = roots[:limit]
+	}
+
+	selected := make(map[int64]struct{}, len(roots))
+	for _, r := range roots {
+		selected[r.ID] = struct{}{}
+	}
+
+	records := repo.threadPosts(int64(id), func(p *post) bool {
+		_, ok := selected[p.path[0]]
+		return ok
+	})
+	sort.Slice(records, func(i, j int) bool {
+		if desc && records[i].path[0] != records[j].path[0] {
+			return records[i].path[0] > records[j].path[0]
+		}
+		return comparePath(records[i].path, records[j].path) < 0
+	})
+
+	return toPosts(records, 0), nil
+}
+
+func (repo *postRepositoryImpl) GetPostTop(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	records := repo.threadPosts(int64(id), func(p *post) bool {
+		if after == nil {
+			return true
+		}
+		cmp := compareTop(p, int64(after.Score), after.ID)
+		if desc {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	sort.Slice(records, func(i, j int) bool {
+		cmp := compareTop(records[i], records[j].Score, records[j].ID)
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	return toPosts(records, limit), nil
+}
+
+func (repo *postRepositoryImpl) GetPostDetails(ctx context.Context, id int64, related string) (*dto.PostInfo, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	p, ok := repo.s.posts[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+
+	postDetails := &dto.PostInfo{}
+	for _, arg := range strings.Split(related, ",") {
+		switch arg {
+		case "user":
+			u, ok := repo.s.users[key(p.Author)]
+			if !ok {
+				return nil, constants.ErrDBNotFound
+			}
+			author := *u
+			postDetails.Author = &author
+		case "thread":
+			t, ok := repo.s.threads[p.Thread]
+			if !ok {
+				return nil, constants.ErrDBNotFound
+			}
+			thread := *t
+			postDetails.Thread = &thread
+		case "forum":
+			f, ok := repo.s.forums[key(p.Forum)]
+			if !ok {
+				return nil, constants.ErrDBNotFound
+			}
+			forum := *f
+			postDetails.Forum = &forum
+		}
+	}
+
+	return postDetails, nil
+}
+
+func (repo *postRepositoryImpl) GetPostByID(ctx context.Context, id int64) (*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	p, ok := repo.s.posts[id]
+	if !ok {
+		return &core.Post{}, constants.ErrDBNotFound
+	}
+	return p.view(), nil
+}
+
+func (repo *postRepositoryImpl) GetPostRevisions(ctx context.Context, id int64) ([]*core.PostRevision, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	revisions := make([]*core.PostRevision, 0, len(repo.s.revisions[id]))
+	for _, r := range repo.s.revisions[id] {
+		revision := *r
+		revisions = append(revisions, &revision)
+	}
+	return revisions, nil
+}
+
+func (repo *postRepositoryImpl) GetPostRevision(ctx context.Context, id int64, revision int64) (*core.PostRevision, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	for _, r := range repo.s.revisions[id] {
+		if r.Revision == revision {
+			rev := *r
+			return &rev, nil
+		}
+	}
+	return nil, constants.ErrDBNotFound
+}
+
+func (repo *postRepositoryImpl) UpdatePost(ctx context.Context, id int64, message string, editor string) (*core.Post, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	p, ok := repo.s.posts[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+	repo.s.revisions[id] = append(repo.s.revisions[id], &core.PostRevision{
+		Revision: int64(len(repo.s.revisions[id]) + 1),
+		Post:     id,
+		Message:  p.Message,
+		Editor:   editor,
+		Created:  time.Now(),
+	})
+	if p.Message != message {
+		repo.s.addEvent(&core.Event{Type: core.EventPostUpdated, Forum: p.Forum, Thread: p.Thread, PostID: id})
+	}
+	p.Message = message
+	p.IsEdited = true
+
+	return p.view(), nil
+}
+
+func (repo *postRepositoryImpl) DeletePost(ctx context.Context, id int64) (*core.Post, error) {
+	return repo.setDeleted(id, true)
+}
+
+func (repo *postRepositoryImpl) RestorePost(ctx context.Context, id int64) (*core.Post, error) {
+	return repo.setDeleted(id, false)
+}
+
+func (repo *postRepositoryImpl) setDeleted(id int64, deleted bool) (*core.Post, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	p, ok := repo.s.posts[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+
+	// Mirrors the update_forum_posts_deleted and post_updated_event triggers.
+	if p.IsDeleted != deleted {
+		if f, ok := repo.s.forums[key(p.Forum)]; ok {
+			if deleted {
+				f.Posts--
+			} else {
+				f.Posts++
+			}
+		}
+		repo.s.addEvent(&core.Event{Type: core.EventPostUpdated, Forum: p.Forum, Thread: p.Thread, PostID: id})
+	}
+	p.IsDeleted = deleted
+
+	return p.view(), nil
+}
+
+func (repo *postRepositoryImpl) DeletePostTree(ctx context.Context, id int64) (int64, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	root, ok := repo.s.posts[id]
+	if !ok {
+		return 0, nil
+	}
+
+	var deleted int64
+	for _, p := range repo.s.posts {
+		if p.Thread != root.Thread || !hasAncestor(p.path, id) {
+			continue
+		}
+		repo.s.deletePost(p)
+		deleted++
+	}
+	return deleted, nil
+}
+
+// threadPosts returns the posts of a thread accepted by filter.
+// The caller must hold the store lock.
+func (repo *postRepositoryImpl) threadPosts(thread int64, filter func(p *post) bool) []*post {
+	records := make([]*post, 0)
+	for _, p := range repo.s.posts {
+		if p.Thread == thread && filter(p) {
+			records = append(records, p)
+		}
+	}
+	return records
+}
+
+func toPosts(records []*post, limit int64) []*core.Post {
+	if limit > 0 && int64(len(records)) > limit {
+		records = records[:limit]
+	}
+
+	posts := make([]*core.Post, 0, len(records))
+	for _, r := range records {
+		posts = append(posts, r.view())
+	}
+	return posts
+}
+
+// view returns a copy of the post as readers see it.
+func (p *post) view() *core.Post {
+	result := p.Post
+	result.Tombstone()
+	return &result
+}
+
+func hasAncestor(path []int64, id int64) bool {
+	for _, ancestor := range path {
+		if ancestor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFlat orders posts by (created, id).
+func compareFlat(p *post, created time.Time, id int64) int {
+	switch {
+	case p.Created.Before(created):
+		return -1
+	case p.Created.After(created):
+		return 1
+	}
+	return compareIDs(p.ID, id)
+}
+
+// compareTop orders posts by score, highest first, and then by id.
+func compareTop(p *post, score int64, id int64) int {
+	switch {
+	case p.Score > score:
+		return -1
+	case p.Score < score:
+		return 1
+	}
+	return compareIDs(p.ID, id)
+}
+
+func compareIDs(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePath orders materialized paths the same way PostgreSQL compares int arrays.
+func comparePath(a, b []int64) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
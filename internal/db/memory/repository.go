@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store keeps every table of the schema in process memory. Keys of
+// case-insensitive columns (citext in the SQL schema) are lower-cased.
+type store struct {
+	mu sync.RWMutex
+
+	users      map[string]*core.User
+	forums     map[string]*core.Forum
+	forumUsers map[string]map[string]string
+	threads    map[int64]*core.Thread
+	threadSlug map[string]int64
+	posts      map[int64]*post
+	votes      map[voteKey]int64
+	postVotes  map[postVoteKey]int64
+	userIDs    map[string]int64
+	revisions  map[int64][]*core.PostRevision
+	passwords  map[string]string
+	roles      map[string]string
+	moderators map[string]map[string]string
+	events     []*core.Event
+	webhooks   map[int64]*core.Webhook
+	deliveries map[int64]*core.WebhookDelivery
+	// notifications keep PostID only; the post is joined when read.
+	notifications map[int64]*core.Notification
+	subscriptions map[subscriptionKey]*core.Subscription
+	// feedSeen is the feed_cursor table.
+	feedSeen map[string]int64
+
+	userSeq   int64
+	threadSeq int64
+	postSeq   int64
+	hookSeq   int64
+	notifySeq int64
+	// deliverySeq survives reset, like the bigserial of webhook_delivery.
+	deliverySeq int64
+	// webhookCursor is the webhook_cursor row, nil until it is first set.
+	webhookCursor *core.EventPosition
+	// eventSeq survives reset, like the bigserial of the event table.
+	eventSeq int64
+	// eventSignal is closed and replaced whenever an event is added.
+	eventSignal chan struct{}
+}
+
+type post struct {
+	core.Post
+	path []int64
+}
+
+type voteKey struct {
+	nickname string
+	thread   int64
+}
+
+type postVoteKey struct {
+	nickname string
+	post     int64
+}
+
+// subscriptionKey has either thread or forum set, like the unique indexes
+// of the subscription table.
+type subscriptionKey struct {
+	nickname string
+	thread   int64
+	forum    string
+}
+
+func newStore() *store {
+	s := &store{}
+	s.reset()
+	return s
+}
+
+func (s *store) reset() {
+	s.users = make(map[string]*core.User)
+	s.forums = make(map[string]*core.Forum)
+	s.forumUsers = make(map[string]map[string]string)
+	s.threads = make(map[int64]*core.Thread)
+	s.threadSlug = make(map[string]int64)
+	s.posts = make(map[int64]*post)
+	s.votes = make(map[voteKey]int64)
+	s.postVotes = make(map[postVoteKey]int64)
+	s.userIDs = make(map[string]int64)
+	s.revisions = make(map[int64][]*core.PostRevision)
+	s.passwords = make(map[string]string)
+	s.roles = make(map[string]string)
+	s.moderators = make(map[string]map[string]string)
+	s.events = nil
+	s.webhooks = make(map[int64]*core.Webhook)
+	s.deliveries = make(map[int64]*core.WebhookDelivery)
+	s.notifications = make(map[int64]*core.Notification)
+	s.subscriptions = make(map[subscriptionKey]*core.Subscription)
+	s.feedSeen = make(map[string]int64)
+	if s.eventSignal == nil {
+		s.eventSignal = make(chan struct{})
+	}
+	// Like the user sequence of postgres, which TRUNCATE does not restart, user
+	// ids are not reused; tokens are bound to them.
+	s.threadSeq = 0
+	s.postSeq = 0
+	s.hookSeq = 0
+	s.notifySeq = 0
+}
+
+// clone returns a deep copy of the store, used to run writes which are
+// thrown away afterwards.
+func (s *store) clone() *store {
+	c := &store{userSeq: s.userSeq, threadSeq: s.threadSeq, postSeq: s.postSeq, hookSeq: s.hookSeq,
+		notifySeq: s.notifySeq, deliverySeq: s.deliverySeq, webhookCursor: s.webhookCursor, eventSeq: s.eventSeq}
+	c.events = append([]*core.Event(nil), s.events...)
+	c.eventSignal = make(chan struct{})
+	c.users = make(map[string]*core.User, len(s.users))
+	for k, u := range s.users {
+		user := *u
+		c.users[k] = &user
+	}
+	c.forums = make(map[string]*core.Forum, len(s.forums))
+	for k, f := range s.forums {
+		forum := *f
+		c.forums[k] = &forum
+	}
+	c.forumUsers = make(map[string]map[string]string, len(s.forumUsers))
+	for k, members := range s.forumUsers {
+		c.forumUsers[k] = make(map[string]string, len(members))
+		for nick, name := range members {
+			c.forumUsers[k][nick] = name
+		}
+	}
+	c.threads = make(map[int64]*core.Thread, len(s.threads))
+	for k, t := range s.threads {
+		thread := *t
+		c.threads[k] = &thread
+	}
+	c.threadSlug = make(map[string]int64, len(s.threadSlug))
+	for k, id := range s.threadSlug {
+		c.threadSlug[k] = id
+	}
+	c.posts = make(map[int64]*post, len(s.posts))
+	for k, p := range s.posts {
+		record := *p
+		c.posts[k] = &record
+	}
+	c.votes = make(map[voteKey]int64, len(s.votes))
+	for k, v := range s.votes {
+		c.votes[k] = v
+	}
+	c.postVotes = make(map[postVoteKey]int64, len(s.postVotes))
+	for k, v := range s.postVotes {
+		c.postVotes[k] = v
+	}
+	c.userIDs = make(map[string]int64, len(s.userIDs))
+	for k, id := range s.userIDs {
+		c.userIDs[k] = id
+	}
+	c.passwords = make(map[string]string, len(s.passwords))
+	for k, hash := range s.passwords {
+		c.passwords[k] = hash
+	}
+	c.roles = make(map[string]string, len(s.roles))
+	for k, role := range s.roles {
+		c.roles[k] = role
+	}
+	c.moderators = make(map[string]map[string]string, len(s.moderators))
+	for k, members := range s.moderators {
+		c.moderators[k] = make(map[string]string, len(members))
+		for nick, name := range members {
+			c.moderators[k][nick] = name
+		}
+	}
+	c.webhooks = make(map[int64]*core.Webhook, len(s.webhooks))
+	for k, w := range s.webhooks {
+		hook := *w
+		hook.Events = append([]string(nil), w.Events...)
+		c.webhooks[k] = &hook
+	}
+	c.deliveries = make(map[int64]*core.WebhookDelivery, len(s.deliveries))
+	for k, d := range s.deliveries {
+		c.deliveries[k] = viewDelivery(d)
+	}
+	c.notifications = make(map[int64]*core.Notification, len(s.notifications))
+	for k, n := range s.notifications {
+		notification := *n
+		c.notifications[k] = &notification
+	}
+	c.subscriptions = make(map[subscriptionKey]*core.Subscription, len(s.subscriptions))
+	for k, sub := range s.subscriptions {
+		subscription := *sub
+		c.subscriptions[k] = &subscription
+	}
+	c.feedSeen = make(map[string]int64, len(s.feedSeen))
+	for k, seen := range s.feedSeen {
+		c.feedSeen[k] = seen
+	}
+	c.revisions = make(map[int64][]*core.PostRevision, len(s.revisions))
+	for k, revisions := range s.revisions {
+		for _, r := range revisions {
+			revision := *r
+			c.revisions[k] = append(c.revisions[k], &revision)
+		}
+	}
+	return c
+}
+
+// deletePost mirrors the delete_count_posts trigger and the post_revision,
+// post_vote and notification foreign keys.
+func (s *store) deletePost(p *post) {
+	delete(s.posts, p.ID)
+	delete(s.revisions, p.ID)
+	for k := range s.postVotes {
+		if k.post == p.ID {
+			delete(s.postVotes, k)
+		}
+	}
+	for id, n := range s.notifications {
+		if n.PostID == p.ID {
+			delete(s.notifications, id)
+		}
+	}
+	if f, ok := s.forums[key(p.Forum)]; ok && !p.IsDeleted {
+		f.Posts--
+	}
+}
+
+// deleteVote mirrors the delete_votes trigger.
+func (s *store) deleteVote(k voteKey) {
+	if t, ok := s.threads[k.thread]; ok {
+		s.setThreadVotes(t, t.Votes-s.votes[k])
+	}
+	delete(s.votes, k)
+}
+
+// deletePostVote mirrors the delete_post_votes trigger.
+func (s *store) deletePostVote(k postVoteKey) {
+	if p, ok := s.posts[k.post]; ok {
+		p.Score -= s.postVotes[k]
+	}
+	delete(s.postVotes, k)
+}
+
+// setThreadVotes mirrors the thread_rank and thread_votes_event triggers.
+func (s *store) setThreadVotes(t *core.Thread, votes int64) {
+	if t.Votes == votes {
+		return
+	}
+	t.Votes = votes
+	t.Hot = core.HotScore(t.Votes, t.Created)
+	s.addEvent(&core.Event{Type: core.EventThreadVotes, Forum: t.Forum, Thread: t.ID, Votes: &votes})
+}
+
+// addEvent mirrors emit_event: it appends the event and wakes the listeners.
+func (s *store) addEvent(event *core.Event) {
+	s.eventSeq++
+	event.ID = s.eventSeq
+	event.Created = time.Now()
+	s.events = append(s.events, event)
+
+	close(s.eventSignal)
+	s.eventSignal = make(chan struct{})
+}
+
+// deleteWebhook mirrors the webhook_delivery foreign key.
+func (s *store) deleteWebhook(id int64) {
+	delete(s.webhooks, id)
+	for k, d := range s.deliveries {
+		if d.Webhook == id {
+			delete(s.deliveries, k)
+		}
+	}
+}
+
+// hasNotification mirrors the unique constraint of the notification table.
+func (s *store) hasNotification(nickname string, post int64, kind string) bool {
+	for _, n := range s.notifications {
+		if n.PostID == post && n.Kind == kind && key(n.Nickname) == key(nickname) {
+			return true
+		}
+	}
+	return false
+}
+
+// addForumUser mirrors the add_forum_user trigger.
+func (s *store) addForumUser(forum string, nickname string) {
+	members, ok := s.forumUsers[key(forum)]
+	if !ok {
+		members = make(map[string]string)
+		s.forumUsers[key(forum)] = members
+	}
+	if _, ok := members[key(nickname)]; !ok {
+		members[key(nickname)] = nickname
+	}
+}
+
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+// NewRepository creates a db.Repository which keeps all data in memory.
+func NewRepository() *db.Repository {
+	s := newStore()
+	return &db.Repository{
+		UserRepo:    &userRepositoryImpl{s: s},
+		ForumRepo:   &forumRepositoryImpl{s: s},
+		ThreadRepo:  &threadRepositoryImpl{s: s},
+		PostRepo:    &postRepositoryImpl{s: s},
+		VoteRepo:    &voteRepositoryImpl{s: s},
+		ServiceRepo: &serviceRepositoryImpl{s: s},
+		SearchRepo:  &searchRepositoryImpl{s: s},
+		EventRepo:   &eventRepositoryImpl{s: s},
+		WebhookRepo: &webhookRepositoryImpl{s: s},
+		NotifyRepo:  &notificationRepositoryImpl{s: s},
+		SubRepo:     &subscriptionRepositoryImpl{s: s},
+		StatsRepo:   &statsRepositoryImpl{s: s},
+	}
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newForum returns a repository holding the user alice and a forum with one
+// thread of hers.
+func newForum(t *testing.T) (*db.Repository, *core.Thread) {
+	ctx := context.Background()
+	repository := NewRepository()
+
+	if err := repository.UserRepo.CreateUser(ctx, &core.User{Nickname: "alice", FullName: "Alice", Email: "alice@example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ForumRepo.CreateForum(ctx, &core.Forum{Title: "Forum", User: "alice", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := repository.ThreadRepo.CreateThread(ctx, &core.Thread{Title: "Thread", Author: "alice", Forum: "forum", Message: "Hello", Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository, thread
+}
+
+func createPosts(t *testing.T, repository *db.Repository, thread *core.Thread, parents ...int64) []int64 {
+	posts := make([]*dto.Post, 0, len(parents))
+	for _, parent := range parents {
+		posts = append(posts, &dto.Post{Parent: parent, Author: "alice", Message: "Reply"})
+	}
+	created, err := repository.PostRepo.CreatePost(context.Background(), thread.Forum, thread.ID, posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]int64, 0, len(created))
+	for _, post := range created {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func TestPostTree(t *testing.T) {
+	repository, thread := newForum(t)
+
+	roots := createPosts(t, repository, thread, 0, 0)
+	children := createPosts(t, repository, thread, roots[0], roots[1], roots[0])
+	grandchild := createPosts(t, repository, thread, children[0])
+
+	tests := []struct {
+		desc bool
+		want []int64
+	}{
+		{false, []int64{roots[0], children[0], grandchild[0], children[2], roots[1], children[1]}},
+		{true, []int64{children[1], roots[1], children[2], grandchild[0], children[0], roots[0]}},
+	}
+	for _, test := range tests {
+		posts, err := repository.PostRepo.GetPostTree(context.Background(), int(thread.ID), nil, test.desc, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]int64, 0, len(posts))
+		for _, post := range posts {
+			got = append(got, post.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tree desc=%t: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+
+	// Limit 0 follows the SQL of each sort: NULLIF makes the tree and top
+	// sorts unlimited, while the flat and parent tree sorts return nothing.
+	ctx := context.Background()
+	limited := []struct {
+		sort  string
+		get   func(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error)
+		count int
+	}{
+		{"flat", repository.PostRepo.GetPost, 0},
+		{"tree", repository.PostRepo.GetPostTree, 6},
+		{"parent_tree", repository.PostRepo.GetPostPredTree, 0},
+		{"top", repository.PostRepo.GetPostTop, 6},
+	}
+	for _, test := range limited {
+		posts, err := test.get(ctx, int(thread.ID), nil, false, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != test.count {
+			t.Errorf("%s with limit 0: got %d posts, want %d", test.sort, len(posts), test.count)
+		}
+	}
+}
+
+func TestCounters(t *testing.T) {
+	ctx := context.Background()
+	repository, thread := newForum(t)
+	createPosts(t, repository, thread, 0, 0, 0)
+
+	forum, err := repository.ForumRepo.GetForumBySlug(ctx, "forum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forum.Threads != 1 || forum.Posts != 3 {
+		t.Errorf("got %d threads and %d posts, want 1 and 3", forum.Threads, forum.Posts)
+	}
+
+	users, err := repository.ForumRepo.GetUsersFromForum(ctx, "forum", 10, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Nickname != "alice" {
+		t.Errorf("got forum users %+v, want alice", users)
+	}
+
+	if err := repository.VoteRepo.CreateVote(ctx, &core.Vote{Nickname: "alice", ThreadID: thread.ID, Voice: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.VoteRepo.UpdateVote(ctx, thread.ID, "alice", -1); err != nil {
+		t.Fatal(err)
+	}
+	voted, err := repository.ThreadRepo.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voted.Votes != -1 {
+		t.Errorf("got %d votes, want -1", voted.Votes)
+	}
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const snippetWords = 35
+
+type searchRepositoryImpl struct {
+	s *store
+}
+
+func (repo *searchRepositoryImpl) Search(ctx context.Context, query *core.SearchQuery) ([]*core.SearchHit, error) {
+	terms := tokenize(query.Text)
+	if len(terms) == 0 {
+		return []*core.SearchHit{}, nil
+	}
+
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	hits := make([]*core.SearchHit, 0)
+	if query.Type != core.SearchHitPost {
+		for _, t := range repo.s.threads {
+			if !matchesFilters(query, t.Forum, t.ID, t.Author, t.Created) {
+				continue
+			}
+			// Title words weigh more, like setweight(..., 'A') in the SQL schema.
+			rank := 2*rankText(t.Title, terms) + rankText(t.Message, terms)
+			if rank == 0 || !containsAll(t.Title+" "+t.Message, terms) {
+				continue
+			}
+			hits = append(hits, &core.SearchHit{Type: core.SearchHitThread, ID: t.ID, Thread: t.ID, Forum: t.Forum,
+				Author: t.Author, Title: t.Title, Snippet: highlight(t.Message, terms), Rank: rank, Created: t.Created})
+		}
+	}
+	if query.Type != core.SearchHitThread {
+		for _, p := range repo.s.posts {
+			if p.IsDeleted || !matchesFilters(query, p.Forum, p.Thread, p.Author, p.Created) || !containsAll(p.Message, terms) {
+				continue
+			}
+			hits = append(hits, &core.SearchHit{Type: core.SearchHitPost, ID: p.ID, Thread: p.Thread, Forum: p.Forum,
+				Author: p.Author, Snippet: highlight(p.Message, terms), Rank: rankText(p.Message, terms), Created: p.Created})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return compareHits(hits[i], hits[j].Rank, hits[j].ID, hits[j].Type) > 0
+	})
+	if query.After != nil {
+		i := sort.Search(len(hits), func(i int) bool {
+			return compareHits(hits[i], query.After.Score, query.After.ID, query.After.Kind) < 0
+		})
+		hits = hits[i:]
+	}
+	if query.Limit > 0 && int64(len(hits)) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+func matchesFilters(query *core.SearchQuery, forum string, thread int64, author string, created time.Time) bool {
+	switch {
+	case query.Forum != "" && key(forum) != key(query.Forum):
+		return false
+	case query.Thread != 0 && thread != query.Thread:
+		return false
+	case query.Author != "" && key(author) != key(query.Author):
+		return false
+	case !query.Since.IsZero() && created.Before(query.Since):
+		return false
+	case !query.Until.IsZero() && !created.Before(query.Until):
+		return false
+	}
+	return true
+}
+
+// compareHits orders hits by (rank, id, kind) like the SQL implementation.
+func compareHits(h *core.SearchHit, rank float64, id int64, kind string) int {
+	switch {
+	case h.Rank != rank:
+		if h.Rank < rank {
+			return -1
+		}
+		return 1
+	case h.ID != id:
+		return compareIDs(h.ID, id)
+	}
+	return strings.Compare(h.Type, kind)
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsAll(text string, terms []string) bool {
+	words := make(map[string]struct{})
+	for _, w := range tokenize(text) {
+		words[w] = struct{}{}
+	}
+	for _, t := range terms {
+		if _, ok := words[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// rankText is the share of words in text which match one of terms.
+func rankText(text string, terms []string) float64 {
+	words := tokenize(text)
+	if len(words) == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, w := range words {
+		for _, t := range terms {
+			if w == t {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(words))
+}
+
+// highlight wraps matching words in <b></b>, starting shortly before the first match.
+func highlight(text string, terms []string) string {
+	words := strings.Fields(text)
+	first := -1
+	for i, w := range words {
+		if matchesAny(w, terms) {
+			words[i] = "<b>" + w + "</b>"
+			if first == -1 {
+				first = i
+			}
+		}
+	}
+
+	start := 0
+	if first > 5 {
+		start = first - 5
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+	return strings.Join(words[start:end], " ")
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, w := range tokenize(word) {
+		for _, t := range terms {
+			if w == t {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+)
+
+type serviceRepositoryImpl struct {
+	s *store
+}
+
+func (repo *serviceRepositoryImpl) Status(ctx context.Context) (*core.Service, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	return &core.Service{
+		User:   int64(len(repo.s.users)),
+		Forum:  int64(len(repo.s.forums)),
+		Thread: int64(len(repo.s.threads)),
+		Post:   int64(len(repo.s.posts)),
+	}, nil
+}
+
+func (repo *serviceRepositoryImpl) Delete(ctx context.Context) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	repo.s.reset()
+	return nil
+}
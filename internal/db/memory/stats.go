@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"time"
+)
+
+type statsRepositoryImpl struct {
+	s *store
+}
+
+// GetForumStats counts the posts and threads themselves instead of the
+// forum_activity and forum_poster aggregates.
+func (repo *statsRepositoryImpl) GetForumStats(ctx context.Context, slug string, since time.Time, bucket string, limit int64) (*core.ForumStats, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	since = since.UTC().Truncate(time.Hour)
+	buckets := make(map[time.Time]*core.ForumActivity)
+	activity := func(created time.Time) *core.ForumActivity {
+		start := core.StatsBucketStart(created, bucket)
+		a, ok := buckets[start]
+		if !ok {
+			a = &core.ForumActivity{Start: start}
+			buckets[start] = a
+		}
+		return a
+	}
+
+	posters := make(map[string]*core.ForumPoster)
+	for _, p := range repo.s.posts {
+		if key(p.Forum) != key(slug) || p.IsDeleted || p.Created.Before(since) {
+			continue
+		}
+		activity(p.Created).Posts++
+		poster, ok := posters[key(p.Author)]
+		if !ok {
+			poster = &core.ForumPoster{Nickname: p.Author}
+			posters[key(p.Author)] = poster
+		}
+		poster.Posts++
+	}
+
+	threads := make([]*core.Thread, 0)
+	for _, t := range repo.s.threads {
+		if key(t.Forum) != key(slug) || t.Created.Before(since) {
+			continue
+		}
+		activity(t.Created).Threads++
+		thread := *t
+		threads = append(threads, &thread)
+	}
+
+	stats := &core.ForumStats{
+		Forum:      slug,
+		Bucket:     bucket,
+		Activity:   make([]*core.ForumActivity, 0, len(buckets)),
+		TopPosters: make([]*core.ForumPoster, 0, len(posters)),
+		TopThreads: threads,
+	}
+	for _, a := range buckets {
+		stats.Activity = append(stats.Activity, a)
+	}
+	sort.Slice(stats.Activity, func(i, j int) bool {
+		return stats.Activity[i].Start.Before(stats.Activity[j].Start)
+	})
+
+	for _, p := range posters {
+		stats.TopPosters = append(stats.TopPosters, p)
+	}
+	sort.Slice(stats.TopPosters, func(i, j int) bool {
+		a, b := stats.TopPosters[i], stats.TopPosters[j]
+		if a.Posts != b.Posts {
+			return a.Posts > b.Posts
+		}
+		return key(a.Nickname) < key(b.Nickname)
+	})
+	if int64(len(stats.TopPosters)) > limit {
+		stats.TopPosters = stats.TopPosters[:limit]
+	}
+
+	sort.Slice(stats.TopThreads, func(i, j int) bool {
+		a, b := stats.TopThreads[i], stats.TopThreads[j]
+		if a.Votes != b.Votes {
+			return a.Votes > b.Votes
+		}
+		return a.ID < b.ID
+	})
+	if int64(len(stats.TopThreads)) > limit {
+		stats.TopThreads = stats.TopThreads[:limit]
+	}
+	return stats, nil
+}
+
+// RepairForumStats has nothing to repair, the stats are always counted.
+func (repo *statsRepositoryImpl) RepairForumStats(ctx context.Context) (bool, error) {
+	return false, nil
+}
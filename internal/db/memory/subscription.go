@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"time"
+)
+
+type subscriptionRepositoryImpl struct {
+	s *store
+}
+
+func (repo *subscriptionRepositoryImpl) SubscribeThread(ctx context.Context, nickname string, thread int64) (*core.Subscription, error) {
+	return repo.subscribe(nickname, subscriptionKey{nickname: key(nickname), thread: thread}, &core.Subscription{Kind: core.SubscriptionThread, Thread: thread})
+}
+
+func (repo *subscriptionRepositoryImpl) SubscribeForum(ctx context.Context, nickname string, forum string) (*core.Subscription, error) {
+	repo.s.mu.RLock()
+	f, ok := repo.s.forums[key(forum)]
+	repo.s.mu.RUnlock()
+	if ok {
+		forum = f.Slug
+	}
+	return repo.subscribe(nickname, subscriptionKey{nickname: key(nickname), forum: key(forum)}, &core.Subscription{Kind: core.SubscriptionForum, Forum: forum})
+}
+
+func (repo *subscriptionRepositoryImpl) subscribe(nickname string, k subscriptionKey, sub *core.Subscription) (*core.Subscription, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if existing, ok := repo.s.subscriptions[k]; ok {
+		subscription := *existing
+		return &subscription, nil
+	}
+	if u, ok := repo.s.users[key(nickname)]; ok {
+		nickname = u.Nickname
+	}
+	sub.Nickname = nickname
+	sub.Since = repo.s.postSeq
+	sub.Created = time.Now()
+	repo.s.subscriptions[k] = sub
+
+	subscription := *sub
+	return &subscription, nil
+}
+
+func (repo *subscriptionRepositoryImpl) UnsubscribeThread(ctx context.Context, nickname string, thread int64) (bool, error) {
+	return repo.unsubscribe(subscriptionKey{nickname: key(nickname), thread: thread}), nil
+}
+
+func (repo *subscriptionRepositoryImpl) UnsubscribeForum(ctx context.Context, nickname string, forum string) (bool, error) {
+	return repo.unsubscribe(subscriptionKey{nickname: key(nickname), forum: key(forum)}), nil
+}
+
+func (repo *subscriptionRepositoryImpl) unsubscribe(k subscriptionKey) bool {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	_, ok := repo.s.subscriptions[k]
+	delete(repo.s.subscriptions, k)
+	return ok
+}
+
+func (repo *subscriptionRepositoryImpl) GetSubscriptions(ctx context.Context, nickname string) ([]*core.Subscription, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	subscriptions := make([]*core.Subscription, 0)
+	for k, sub := range repo.s.subscriptions {
+		if k.nickname == key(nickname) {
+			subscription := *sub
+			subscriptions = append(subscriptions, &subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		if a.Thread != b.Thread {
+			return a.Thread < b.Thread
+		}
+		return key(a.Forum) < key(b.Forum)
+	})
+	return subscriptions, nil
+}
+
+func (repo *subscriptionRepositoryImpl) GetFeed(ctx context.Context, nickname string, limit int64) ([]*core.Post, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	nick := key(nickname)
+	seen := repo.s.feedSeen[nick]
+	posts := make([]*core.Post, 0)
+	for _, p := range repo.s.posts {
+		if p.ID <= seen || p.IsDeleted || key(p.Author) == nick {
+			continue
+		}
+		sub, ok := repo.s.subscriptions[subscriptionKey{nickname: nick, thread: p.Thread}]
+		if !ok || p.ID <= sub.Since {
+			sub, ok = repo.s.subscriptions[subscriptionKey{nickname: nick, forum: key(p.Forum)}]
+		}
+		if ok && p.ID > sub.Since {
+			posts = append(posts, p.view())
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+	if int64(len(posts)) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (repo *subscriptionRepositoryImpl) SetFeedSeen(ctx context.Context, nickname string, post int64) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.users[key(nickname)]; ok && post > repo.s.feedSeen[key(nickname)] {
+		repo.s.feedSeen[key(nickname)] = post
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"context"
+	"strconv"
+)
+
+type threadRepositoryImpl struct {
+	s *store
+}
+
+func (repo *threadRepositoryImpl) CreateThread(ctx context.Context, thread *core.Thread) (*core.Thread, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	repo.s.threadSeq++
+	t := *thread
+	t.ID = repo.s.threadSeq
+	t.Votes = 0
+	t.State = core.ThreadOpen
+	// Mirrors the thread_rank trigger.
+	t.Active = t.Created
+	t.Hot = core.HotScore(t.Votes, t.Created)
+	repo.s.threads[t.ID] = &t
+	if t.Slug != "" {
+		repo.s.threadSlug[key(t.Slug)] = t.ID
+	}
+
+	// Mirrors the insert_thread, forum_user and thread_created_event triggers.
+	if f, ok := repo.s.forums[key(t.Forum)]; ok {
+		f.Threads++
+	}
+	repo.s.addForumUser(t.Forum, t.Author)
+	repo.s.addEvent(&core.Event{Type: core.EventThreadCreated, Forum: t.Forum, Thread: t.ID})
+
+	created := t
+	return &created, nil
+}
+
+func (repo *threadRepositoryImpl) GetThread(ctx context.Context, slug string) (*core.Thread, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	id, ok := repo.s.threadSlug[key(slug)]
+	if !ok {
+		return &core.Thread{}, constants.ErrDBNotFound
+	}
+	thread := *repo.s.threads[id]
+	return &thread, nil
+}
+
+func (repo *threadRepositoryImpl) GetThreadByID(ctx context.Context, id int64) (*core.Thread, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	t, ok := repo.s.threads[id]
+	if !ok {
+		return &core.Thread{}, constants.ErrDBNotFound
+	}
+	thread := *t
+	return &thread, nil
+}
+
+func (repo *threadRepositoryImpl) ResolveThread(ctx context.Context, slugOrID string) (*core.Thread, error) {
+	if id, err := strconv.ParseInt(slugOrID, 10, 64); err == nil {
+		return repo.GetThreadByID(ctx, id)
+	}
+	return repo.GetThread(ctx, slugOrID)
+}
+
+func (repo *threadRepositoryImpl) UpdateThread(ctx context.Context, id int64, title string, message string) (*core.Thread, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	t, ok := repo.s.threads[id]
+	if !ok {
+		return &core.Thread{}, constants.ErrDBNotFound
+	}
+	t.Title = title
+	t.Message = message
+
+	thread := *t
+	return &thread, nil
+}
+
+func (repo *threadRepositoryImpl) UpdateThreadState(ctx context.Context, id int64, state string) (*core.Thread, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	t, ok := repo.s.threads[id]
+	if !ok {
+		return &core.Thread{}, constants.ErrDBNotFound
+	}
+	t.State = state
+
+	thread := *t
+	return &thread, nil
+}
@@ -0,0 +1,399 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"context"
+	"strings"
+)
+
+type userRepositoryImpl struct {
+	s *store
+}
+
+func (repo *userRepositoryImpl) CreateUser(ctx context.Context, user *core.User, passwordHash string) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.users[key(user.Nickname)]; ok {
+		return constants.ErrUserAlreadyExists
+	}
+	if repo.findByEmail(user.Email) != nil {
+		return constants.ErrUserAlreadyExists
+	}
+
+	u := *user
+	repo.s.users[key(user.Nickname)] = &u
+	repo.s.userSeq++
+	repo.s.userIDs[key(user.Nickname)] = repo.s.userSeq
+	repo.s.passwords[key(user.Nickname)] = passwordHash
+	return nil
+}
+
+func (repo *userRepositoryImpl) GetUserByNickname(ctx context.Context, nickname string) (*core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	u, ok := repo.s.users[key(nickname)]
+	if !ok {
+		return &core.User{}, constants.ErrDBNotFound
+	}
+	user := *u
+	return &user, nil
+}
+
+func (repo *userRepositoryImpl) GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	users := make([]*core.User, 0, len(nicknames))
+	seen := make(map[string]bool, len(nicknames))
+	for _, nickname := range nicknames {
+		u, ok := repo.s.users[key(nickname)]
+		if !ok || seen[key(nickname)] {
+			continue
+		}
+		seen[key(nickname)] = true
+		user := *u
+		users = append(users, &user)
+	}
+	return users, nil
+}
+
+func (repo *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	u := repo.findByEmail(email)
+	if u == nil {
+		return &core.User{}, constants.ErrDBNotFound
+	}
+	user := *u
+	return &user, nil
+}
+
+func (repo *userRepositoryImpl) GetSimilaryUsers(ctx context.Context, email string, nickname string) ([]core.User, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	var users []core.User
+	for _, u := range repo.s.users {
+		if key(u.Email) == key(email) || key(u.Nickname) == key(nickname) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (repo *userRepositoryImpl) UpdateUser(ctx context.Context, user *core.User) (*core.User, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	u, ok := repo.s.users[key(user.Nickname)]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+
+	if email := strings.TrimSpace(user.Email); email != "" {
+		if other := repo.findByEmail(email); other != nil && other != u {
+			return nil, constants.ErrUserAlreadyExists
+		}
+		u.Email = email
+	}
+	if fullName := strings.TrimSpace(user.FullName); fullName != "" {
+		u.FullName = fullName
+	}
+	if about := strings.TrimSpace(user.About); about != "" {
+		u.About = about
+	}
+
+	return &core.User{Nickname: user.Nickname, FullName: u.FullName, About: u.About, Email: u.Email}, nil
+}
+
+func (repo *userRepositoryImpl) GetPasswordHash(ctx context.Context, nickname string) (string, string, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	u, ok := repo.s.users[key(nickname)]
+	if !ok {
+		return "", "", constants.ErrDBNotFound
+	}
+	return u.Nickname, repo.s.passwords[key(nickname)], nil
+}
+
+func (repo *userRepositoryImpl) SetPasswordHash(ctx context.Context, nickname string, passwordHash string) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.users[key(nickname)]; !ok {
+		return constants.ErrDBNotFound
+	}
+	repo.s.passwords[key(nickname)] = passwordHash
+	return nil
+}
+
+func (repo *userRepositoryImpl) GetUserID(ctx context.Context, nickname string) (int64, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	id, ok := repo.s.userIDs[key(nickname)]
+	if !ok {
+		return 0, constants.ErrDBNotFound
+	}
+	return id, nil
+}
+
+func (repo *userRepositoryImpl) GetUserRole(ctx context.Context, nickname string) (string, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	if _, ok := repo.s.users[key(nickname)]; !ok {
+		return "", constants.ErrDBNotFound
+	}
+	if role, ok := repo.s.roles[key(nickname)]; ok {
+		return role, nil
+	}
+	return core.RoleUser, nil
+}
+
+func (repo *userRepositoryImpl) SetUserRole(ctx context.Context, nickname string, role string) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.users[key(nickname)]; !ok {
+		return constants.ErrDBNotFound
+	}
+	repo.s.roles[key(nickname)] = role
+	return nil
+}
+
+func (repo *userRepositoryImpl) DeleteUser(ctx context.Context, nickname string, mode string, dryRun bool) (*core.UserDeletion, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	u, ok := repo.s.users[key(nickname)]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+	report := &core.UserDeletion{
+		Nickname:    u.Nickname,
+		Placeholder: core.DeletedUserNickname(repo.s.userIDs[key(nickname)]),
+		Mode:        mode,
+		DryRun:      dryRun,
+	}
+	if _, ok := repo.s.users[key(report.Placeholder)]; ok {
+		return nil, constants.ErrUserAlreadyExists
+	}
+
+	// A dry run works on a copy, like the rolled back transaction of the
+	// postgres repository.
+	s := repo.s
+	if dryRun {
+		s = s.clone()
+	}
+
+	if mode == core.UserDeletePurge {
+		purgeUserContent(s, report)
+	} else {
+		addPlaceholder(s, report.Placeholder)
+		reassignUserContent(s, report)
+	}
+
+	var revised int64
+	for _, revisions := range s.revisions {
+		for _, r := range revisions {
+			if key(r.Editor) == key(report.Nickname) {
+				r.Editor = report.Placeholder
+				revised++
+			}
+		}
+	}
+	for _, f := range s.forums {
+		if key(f.User) == key(report.Nickname) {
+			f.User = report.Placeholder
+			report.Forums++
+		}
+	}
+	if mode == core.UserDeletePurge {
+		if report.Tombstoned+report.Forums+revised == 0 {
+			report.Placeholder = ""
+		} else {
+			addPlaceholder(s, report.Placeholder)
+		}
+	}
+	for _, members := range s.forumUsers {
+		delete(members, key(report.Nickname))
+	}
+	delete(s.users, key(report.Nickname))
+	delete(s.userIDs, key(report.Nickname))
+	delete(s.passwords, key(report.Nickname))
+	delete(s.roles, key(report.Nickname))
+	for id, n := range s.notifications {
+		if key(n.Nickname) == key(report.Nickname) {
+			delete(s.notifications, id)
+		}
+	}
+	for _, members := range s.moderators {
+		delete(members, key(report.Nickname))
+	}
+	for k := range s.subscriptions {
+		if k.nickname == key(report.Nickname) {
+			delete(s.subscriptions, k)
+		}
+	}
+	delete(s.feedSeen, key(report.Nickname))
+
+	return report, nil
+}
+
+func addPlaceholder(s *store, nickname string) {
+	s.users[key(nickname)] = &core.User{
+		Nickname: nickname,
+		FullName: core.DeletedUserFullName,
+		Email:    nickname + "@deleted.invalid",
+	}
+	s.userSeq++
+	s.userIDs[key(nickname)] = s.userSeq
+}
+
+func reassignUserContent(s *store, report *core.UserDeletion) {
+	nick := key(report.Nickname)
+	for _, t := range s.threads {
+		if key(t.Author) == nick {
+			t.Author = report.Placeholder
+			report.Threads++
+		}
+	}
+	for _, p := range s.posts {
+		if key(p.Author) == nick {
+			p.Author = report.Placeholder
+			report.Posts++
+		}
+	}
+	for k, voice := range s.votes {
+		if k.nickname == nick {
+			delete(s.votes, k)
+			s.votes[voteKey{nickname: key(report.Placeholder), thread: k.thread}] = voice
+			report.Votes++
+		}
+	}
+	for k, voice := range s.postVotes {
+		if k.nickname == nick {
+			delete(s.postVotes, k)
+			s.postVotes[postVoteKey{nickname: key(report.Placeholder), post: k.post}] = voice
+			report.Votes++
+		}
+	}
+	for _, members := range s.forumUsers {
+		if _, ok := members[nick]; ok {
+			members[key(report.Placeholder)] = report.Placeholder
+		}
+	}
+}
+
+func purgeUserContent(s *store, report *core.UserDeletion) {
+	nick := key(report.Nickname)
+	for k := range s.votes {
+		if k.nickname == nick {
+			s.deleteVote(k)
+			report.Votes++
+		}
+	}
+	for k := range s.postVotes {
+		if k.nickname == nick {
+			s.deletePostVote(k)
+			report.Votes++
+		}
+	}
+
+	threads := make(map[int64]bool)
+	forums := make(map[string]bool)
+	for id, t := range s.threads {
+		if key(t.Author) == nick {
+			threads[id] = true
+			forums[key(t.Forum)] = true
+		}
+	}
+	for k := range s.votes {
+		if threads[k.thread] {
+			s.deleteVote(k)
+		}
+	}
+	for _, p := range s.posts {
+		if threads[p.Thread] {
+			s.deletePost(p)
+			report.Posts++
+		}
+	}
+	for k := range s.subscriptions {
+		if threads[k.thread] {
+			delete(s.subscriptions, k)
+		}
+	}
+	for id := range threads {
+		t := s.threads[id]
+		delete(s.threadSlug, key(t.Slug))
+		delete(s.threads, id)
+		if f, ok := s.forums[key(t.Forum)]; ok {
+			f.Threads--
+		}
+		report.Threads++
+	}
+
+	replied := make(map[int64]bool)
+	for _, p := range s.posts {
+		replied[p.Pred] = true
+	}
+	for _, p := range s.posts {
+		if key(p.Author) != nick {
+			continue
+		}
+		if replied[p.ID] {
+			if f, ok := s.forums[key(p.Forum)]; ok && !p.IsDeleted {
+				f.Posts--
+			}
+			if !p.IsDeleted || p.Message != "" {
+				s.addEvent(&core.Event{Type: core.EventPostUpdated, Forum: p.Forum, Thread: p.Thread, PostID: p.ID})
+			}
+			p.IsDeleted = true
+			p.Message = ""
+			p.Author = report.Placeholder
+			report.Tombstoned++
+		} else {
+			s.deletePost(p)
+			report.Posts++
+		}
+	}
+
+	for forum := range forums {
+		for member := range s.forumUsers[forum] {
+			if !hasContent(s, forum, member) {
+				delete(s.forumUsers[forum], member)
+			}
+		}
+	}
+}
+
+// hasContent reports whether nickname has a thread or post in forum.
+func hasContent(s *store, forum string, nickname string) bool {
+	for _, t := range s.threads {
+		if key(t.Forum) == forum && key(t.Author) == nickname {
+			return true
+		}
+	}
+	for _, p := range s.posts {
+		if key(p.Forum) == forum && key(p.Author) == nickname {
+			return true
+		}
+	}
+	return false
+}
+
+func (repo *userRepositoryImpl) findByEmail(email string) *core.User {
+	for _, u := range repo.s.users {
+		if key(u.Email) == key(email) {
+			return u
+		}
+	}
+	return nil
+}
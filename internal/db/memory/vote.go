@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+)
+
+type voteRepositoryImpl struct {
+	s *store
+}
+
+func (repo *voteRepositoryImpl) CreateVote(ctx context.Context, vote *core.Vote) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	repo.s.votes[voteKey{nickname: key(vote.Nickname), thread: vote.ThreadID}] = vote.Voice
+
+	// Mirrors the insert_votes trigger.
+	if t, ok := repo.s.threads[vote.ThreadID]; ok {
+		repo.s.setThreadVotes(t, t.Votes+vote.Voice)
+	}
+	return nil
+}
+
+func (repo *voteRepositoryImpl) GetVote(ctx context.Context, nickname string, threadID int64) (int64, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	voice, ok := repo.s.votes[voteKey{nickname: key(nickname), thread: threadID}]
+	if !ok {
+		return 0, constants.ErrDBNotFound
+	}
+	return voice, nil
+}
+
+func (repo *voteRepositoryImpl) UpdateVote(ctx context.Context, threadID int64, nickname string, voice int64) (bool, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	k := voteKey{nickname: key(nickname), thread: threadID}
+	old, ok := repo.s.votes[k]
+	if !ok || old == voice {
+		return false, nil
+	}
+	repo.s.votes[k] = voice
+
+	// Mirrors the update_votes trigger.
+	if t, ok := repo.s.threads[threadID]; ok {
+		repo.s.setThreadVotes(t, t.Votes+voice-old)
+	}
+	return true, nil
+}
+
+func (repo *voteRepositoryImpl) DeleteVote(ctx context.Context, threadID int64, nickname string) (int64, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	k := voteKey{nickname: key(nickname), thread: threadID}
+	voice, ok := repo.s.votes[k]
+	if !ok {
+		return 0, constants.ErrDBNotFound
+	}
+	repo.s.deleteVote(k)
+	return voice, nil
+}
+
+func (repo *voteRepositoryImpl) GetVotes(ctx context.Context, threadID int64, limit int64, after *core.Cursor) ([]*core.Vote, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	votes := make([]*core.Vote, 0)
+	for k, voice := range repo.s.votes {
+		if k.thread != threadID || after != nil && k.nickname <= key(after.Nickname) {
+			continue
+		}
+		nickname := k.nickname
+		if u, ok := repo.s.users[k.nickname]; ok {
+			nickname = u.Nickname
+		}
+		votes = append(votes, &core.Vote{Nickname: nickname, ThreadID: threadID, Voice: voice})
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		return key(votes[i].Nickname) < key(votes[j].Nickname)
+	})
+	if limit > 0 && int64(len(votes)) > limit {
+		votes = votes[:limit]
+	}
+	return votes, nil
+}
+
+func (repo *voteRepositoryImpl) CreatePostVote(ctx context.Context, vote *core.PostVote) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	repo.s.postVotes[postVoteKey{nickname: key(vote.Nickname), post: vote.PostID}] = vote.Voice
+
+	// Mirrors the insert_post_votes trigger.
+	if p, ok := repo.s.posts[vote.PostID]; ok {
+		p.Score += vote.Voice
+	}
+	return nil
+}
+
+func (repo *voteRepositoryImpl) PostVoteExists(ctx context.Context, nickname string, postID int64) (bool, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	_, ok := repo.s.postVotes[postVoteKey{nickname: key(nickname), post: postID}]
+	return ok, nil
+}
+
+func (repo *voteRepositoryImpl) UpdatePostVote(ctx context.Context, postID int64, nickname string, voice int64) (bool, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	k := postVoteKey{nickname: key(nickname), post: postID}
+	old, ok := repo.s.postVotes[k]
+	if !ok || old == voice {
+		return false, nil
+	}
+	repo.s.postVotes[k] = voice
+
+	// Mirrors the update_post_votes trigger.
+	if p, ok := repo.s.posts[postID]; ok {
+		p.Score += voice - old
+	}
+	return true, nil
+}
@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/model/core"
+	"context"
+	"sort"
+	"time"
+)
+
+type webhookRepositoryImpl struct {
+	s *store
+}
+
+func (repo *webhookRepositoryImpl) CreateWebhook(ctx context.Context, hook *core.Webhook) (*core.Webhook, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	f, ok := repo.s.forums[key(hook.Forum)]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+
+	repo.s.hookSeq++
+	record := *hook
+	record.ID = repo.s.hookSeq
+	record.Forum = f.Slug
+	record.Events = append([]string(nil), hook.Events...)
+	record.Created = time.Now()
+	repo.s.webhooks[record.ID] = &record
+
+	created := record
+	return &created, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhook(ctx context.Context, id int64) (*core.Webhook, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	w, ok := repo.s.webhooks[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+	hook := *w
+	return &hook, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhooks(ctx context.Context, forum string) ([]*core.Webhook, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	hooks := make([]*core.Webhook, 0)
+	for _, w := range repo.s.webhooks {
+		if forum == "" || key(w.Forum) == key(forum) {
+			hook := *w
+			hooks = append(hooks, &hook)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks, nil
+}
+
+func (repo *webhookRepositoryImpl) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	if _, ok := repo.s.webhooks[id]; !ok {
+		return false, nil
+	}
+	repo.s.deleteWebhook(id)
+	return true, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhookCursor(ctx context.Context) (core.EventPosition, bool, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	if repo.s.webhookCursor == nil {
+		return core.EventPosition{}, false, nil
+	}
+	return *repo.s.webhookCursor, true, nil
+}
+
+func (repo *webhookRepositoryImpl) QueueDeliveries(ctx context.Context, deliveries []*core.WebhookDelivery, cursor core.EventPosition) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	queued := make(map[[2]int64]bool, len(repo.s.deliveries))
+	for _, d := range repo.s.deliveries {
+		queued[[2]int64{d.Webhook, d.Event}] = true
+	}
+	for _, d := range deliveries {
+		if _, ok := repo.s.webhooks[d.Webhook]; !ok {
+			return constants.ErrDBNotFound
+		}
+		if queued[[2]int64{d.Webhook, d.Event}] {
+			continue
+		}
+		repo.s.deliverySeq++
+		record := *d
+		record.ID = repo.s.deliverySeq
+		record.Status = core.DeliveryPending
+		record.Attempts = 0
+		record.Created = time.Now()
+		record.History = make([]*core.WebhookAttempt, 0)
+		repo.s.deliveries[record.ID] = &record
+	}
+
+	if repo.s.webhookCursor == nil || cursor.After(*repo.s.webhookCursor) {
+		repo.s.webhookCursor = &cursor
+	}
+	return nil
+}
+
+func (repo *webhookRepositoryImpl) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]*core.WebhookDelivery, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	due := make([]*core.WebhookDelivery, 0)
+	for _, d := range repo.s.deliveries {
+		if d.Status == core.DeliveryPending && d.NextAttempt != nil && !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttempt.Before(*due[j].NextAttempt)
+	})
+	if int64(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*core.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		next := now.Add(lease)
+		d.NextAttempt = &next
+
+		delivery := *d
+		delivery.History = nil
+		if w, ok := repo.s.webhooks[d.Webhook]; ok {
+			delivery.URL = w.URL
+			delivery.Secret = w.Secret
+		}
+		claimed = append(claimed, &delivery)
+	}
+	return claimed, nil
+}
+
+func (repo *webhookRepositoryImpl) RecordAttempt(ctx context.Context, attempt *core.WebhookAttempt, status string, nextAttempt *time.Time) error {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	d, ok := repo.s.deliveries[attempt.Delivery]
+	if !ok {
+		return constants.ErrDBNotFound
+	}
+	record := *attempt
+	record.Attempt = int64(len(d.History) + 1)
+	attempt.Attempt = record.Attempt
+	d.History = append(d.History, &record)
+	d.Status = status
+	d.Attempts++
+	d.NextAttempt = nextAttempt
+	return nil
+}
+
+func (repo *webhookRepositoryImpl) GetDelivery(ctx context.Context, id int64) (*core.WebhookDelivery, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	d, ok := repo.s.deliveries[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+	return viewDelivery(d), nil
+}
+
+func (repo *webhookRepositoryImpl) GetDeliveries(ctx context.Context, webhook int64, before int64, limit int64) ([]*core.WebhookDelivery, error) {
+	repo.s.mu.RLock()
+	defer repo.s.mu.RUnlock()
+
+	records := make([]*core.WebhookDelivery, 0)
+	for _, d := range repo.s.deliveries {
+		if d.Webhook == webhook && (before == 0 || d.ID < before) {
+			records = append(records, d)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID > records[j].ID
+	})
+	if int64(len(records)) > limit {
+		records = records[:limit]
+	}
+
+	deliveries := make([]*core.WebhookDelivery, 0, len(records))
+	for _, d := range records {
+		deliveries = append(deliveries, viewDelivery(d))
+	}
+	return deliveries, nil
+}
+
+func (repo *webhookRepositoryImpl) Redeliver(ctx context.Context, id int64, now time.Time) (*core.WebhookDelivery, error) {
+	repo.s.mu.Lock()
+	defer repo.s.mu.Unlock()
+
+	d, ok := repo.s.deliveries[id]
+	if !ok {
+		return nil, constants.ErrDBNotFound
+	}
+	d.Status = core.DeliveryPending
+	d.Attempts = 0
+	d.NextAttempt = &now
+	return viewDelivery(d), nil
+}
+
+// viewDelivery copies a delivery with its history.
+func viewDelivery(d *core.WebhookDelivery) *core.WebhookDelivery {
+	delivery := *d
+	delivery.History = make([]*core.WebhookAttempt, 0, len(d.History))
+	for _, a := range d.History {
+		attempt := *a
+		delivery.History = append(delivery.History, &attempt)
+	}
+	return &delivery
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "vote", "post", "thread", "forum_user", "forum", "user" CASCADE;
+
+DROP FUNCTION IF EXISTS update_votes();
+DROP FUNCTION IF EXISTS make_votes();
+DROP FUNCTION IF EXISTS increment_forum_posts();
+DROP FUNCTION IF EXISTS new_path();
+DROP FUNCTION IF EXISTS increment_thread();
+DROP FUNCTION IF EXISTS add_forum_user();
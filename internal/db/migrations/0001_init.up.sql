@@ -12,7 +12,6 @@ CREATE UNLOGGED TABLE IF NOT EXISTS "user" (
 CREATE INDEX IF NOT EXISTS index_user_nickname_hash ON "user" USING HASH ("nickname");
 CREATE INDEX IF NOT EXISTS index_user_email_hash ON "user" (nickname, email);
 
-
 ----------------------------------------------------------------- FORUM
 CREATE UNLOGGED TABLE IF NOT EXISTS "forum" (
     id      serial,
@@ -42,18 +41,6 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
-CREATE TRIGGER forum_user
-    AFTER INSERT
-    ON post
-    FOR EACH ROW
-EXECUTE PROCEDURE add_forum_user();
-
-CREATE TRIGGER forum_user
-    AFTER INSERT
-    ON thread
-    FOR EACH ROW
-EXECUTE PROCEDURE add_forum_user();
-
 ----------------------------------------------------------------- THREAD
 CREATE UNLOGGED TABLE IF NOT EXISTS "thread" (
     id     serial PRIMARY KEY,
@@ -70,6 +57,29 @@ CREATE INDEX IF NOT EXISTS index_thread_slug_hash ON "thread" USING HASH ("slug"
 CREATE INDEX IF NOT EXISTS index_thread_id_hash ON "thread" USING HASH ("forum");
 CREATE INDEX IF NOT EXISTS index_thread_forum_created ON "thread" ("forum", "created");
 
+DROP TRIGGER IF EXISTS forum_user ON "thread";
+CREATE TRIGGER forum_user
+    AFTER INSERT
+    ON thread
+    FOR EACH ROW
+EXECUTE PROCEDURE add_forum_user();
+
+CREATE OR REPLACE FUNCTION increment_thread() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE "forum"
+    SET threads = threads + 1
+    WHERE slug=NEW.forum;
+    RETURN NEW;
+END;
+$$ LANGUAGE  plpgsql;
+
+DROP TRIGGER IF EXISTS insert_thread ON "thread";
+CREATE TRIGGER insert_thread
+    AFTER INSERT
+    ON thread
+    FOR EACH ROW
+EXECUTE PROCEDURE increment_thread();
 
 ----------------------------------------------------------------- POST
 CREATE UNLOGGED TABLE IF NOT EXISTS "post" (
@@ -84,27 +94,15 @@ CREATE UNLOGGED TABLE IF NOT EXISTS "post" (
     created  timestamptz NOT NULL
 );
 
-
 CREATE INDEX IF NOT EXISTS index_post_thread_id ON "post"("thread", "path");
 CREATE INDEX IF NOT EXISTS index_post_path_complex ON "post" ((path[1]), path);
 
-
-CREATE OR REPLACE FUNCTION increment_thread() RETURNS TRIGGER AS
-$$
-BEGIN
-    UPDATE "forum"
-    SET threads = threads + 1
-    WHERE slug=NEW.forum;
-    RETURN NEW;
-END;
-$$ LANGUAGE  plpgsql;
-
-CREATE TRIGGER insert_thread
+DROP TRIGGER IF EXISTS forum_user ON "post";
+CREATE TRIGGER forum_user
     AFTER INSERT
-    ON thread
+    ON post
     FOR EACH ROW
-EXECUTE PROCEDURE increment_thread();
-
+EXECUTE PROCEDURE add_forum_user();
 
 CREATE OR REPLACE FUNCTION new_path() RETURNS TRIGGER AS $$
 BEGIN
@@ -115,12 +113,12 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS update_path ON "post";
 CREATE TRIGGER update_path
     BEFORE INSERT ON "post"
     FOR EACH ROW
     EXECUTE PROCEDURE new_path();
 
-
 CREATE OR REPLACE FUNCTION increment_forum_posts() RETURNS TRIGGER AS $$
 BEGIN
     UPDATE "forum"
@@ -130,6 +128,7 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS update_count_posts ON "post";
 CREATE TRIGGER update_count_posts
     AFTER INSERT
     ON "post"
@@ -145,7 +144,6 @@ CREATE UNLOGGED TABLE IF NOT EXISTS "vote" (
 
 CREATE INDEX IF NOT EXISTS index_vote_update ON "vote" ("nickname", "thread", "voice");
 
-
 CREATE OR REPLACE FUNCTION make_votes() RETURNS TRIGGER AS $$
 BEGIN
     UPDATE "thread"
@@ -155,13 +153,13 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS insert_votes ON "vote";
 CREATE TRIGGER insert_votes
     AFTER INSERT
     ON "vote"
     FOR EACH ROW
 EXECUTE PROCEDURE make_votes();
 
-
 CREATE OR REPLACE FUNCTION update_votes() RETURNS TRIGGER AS $$
 BEGIN
     UPDATE "thread"
@@ -171,10 +169,9 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS update_votes ON "vote";
 CREATE TRIGGER update_votes
     AFTER UPDATE
     ON "vote"
     FOR EACH ROW
 EXECUTE PROCEDURE update_votes();
-
-VACUUM ANALYZE;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS index_post_thread_created_id;
+
+DROP INDEX IF EXISTS index_thread_forum_created_id;
+CREATE INDEX IF NOT EXISTS index_thread_forum_created ON "thread" ("forum", "created");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS index_thread_forum_created;
+CREATE INDEX IF NOT EXISTS index_thread_forum_created_id ON "thread" ("forum", "created", "id");
+
+CREATE INDEX IF NOT EXISTS index_post_thread_created_id ON "post" ("thread", "created", "id");
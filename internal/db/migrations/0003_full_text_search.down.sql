@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS index_thread_search;
+DROP TRIGGER IF EXISTS thread_search ON "thread";
+DROP FUNCTION IF EXISTS thread_search();
+ALTER TABLE "thread" DROP COLUMN IF EXISTS search;
+
+DROP INDEX IF EXISTS index_post_search;
+DROP TRIGGER IF EXISTS post_search ON "post";
+DROP FUNCTION IF EXISTS post_search();
+ALTER TABLE "post" DROP COLUMN IF EXISTS search;
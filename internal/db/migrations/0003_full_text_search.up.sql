@@ -0,0 +1,44 @@
+----------------------------------------------------------------- POST
+ALTER TABLE "post" ADD COLUMN IF NOT EXISTS search tsvector;
+
+CREATE OR REPLACE FUNCTION post_search() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search = to_tsvector('simple', NEW.message);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS post_search ON "post";
+CREATE TRIGGER post_search
+    BEFORE INSERT OR UPDATE OF message
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE post_search();
+
+UPDATE "post" SET search = to_tsvector('simple', message) WHERE search IS NULL;
+
+CREATE INDEX IF NOT EXISTS index_post_search ON "post" USING GIN (search);
+
+----------------------------------------------------------------- THREAD
+ALTER TABLE "thread" ADD COLUMN IF NOT EXISTS search tsvector;
+
+CREATE OR REPLACE FUNCTION thread_search() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search = setweight(to_tsvector('simple', NEW.title), 'A') ||
+                 setweight(to_tsvector('simple', NEW.message), 'B');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_search ON "thread";
+CREATE TRIGGER thread_search
+    BEFORE INSERT OR UPDATE OF title, message
+    ON "thread"
+    FOR EACH ROW
+EXECUTE PROCEDURE thread_search();
+
+UPDATE "thread"
+SET search = setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', message), 'B')
+WHERE search IS NULL;
+
+CREATE INDEX IF NOT EXISTS index_thread_search ON "thread" USING GIN (search);
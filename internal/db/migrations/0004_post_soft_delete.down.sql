@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS delete_count_posts ON "post";
+DROP FUNCTION IF EXISTS decrement_forum_posts();
+
+DROP TRIGGER IF EXISTS update_forum_posts_deleted ON "post";
+DROP FUNCTION IF EXISTS update_forum_posts_deleted();
+
+ALTER TABLE "post" DROP COLUMN IF EXISTS isDeleted;
@@ -0,0 +1,36 @@
+ALTER TABLE "post" ADD COLUMN IF NOT EXISTS isDeleted bool NOT NULL DEFAULT FALSE;
+
+-- forum.posts only counts posts which are not deleted.
+CREATE OR REPLACE FUNCTION update_forum_posts_deleted() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.isDeleted AND NOT OLD.isDeleted THEN
+        UPDATE "forum" SET posts = posts - 1 WHERE slug = NEW.forum;
+    ELSIF OLD.isDeleted AND NOT NEW.isDeleted THEN
+        UPDATE "forum" SET posts = posts + 1 WHERE slug = NEW.forum;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_forum_posts_deleted ON "post";
+CREATE TRIGGER update_forum_posts_deleted
+    AFTER UPDATE OF isDeleted
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE update_forum_posts_deleted();
+
+CREATE OR REPLACE FUNCTION decrement_forum_posts() RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT OLD.isDeleted THEN
+        UPDATE "forum" SET posts = posts - 1 WHERE slug = OLD.forum;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS delete_count_posts ON "post";
+CREATE TRIGGER delete_count_posts
+    AFTER DELETE
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE decrement_forum_posts();
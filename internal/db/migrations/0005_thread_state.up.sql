@@ -0,0 +1,2 @@
+ALTER TABLE "thread" ADD COLUMN IF NOT EXISTS state text NOT NULL DEFAULT 'open'
+    CHECK (state IN ('open', 'closed', 'locked', 'archived'));
@@ -0,0 +1 @@
+ALTER TABLE "forum" ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '';
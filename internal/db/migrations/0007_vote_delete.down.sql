@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS delete_votes ON "vote";
+DROP FUNCTION IF EXISTS delete_votes();
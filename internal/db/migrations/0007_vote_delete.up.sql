@@ -0,0 +1,15 @@
+CREATE OR REPLACE FUNCTION delete_votes() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "thread"
+    SET votes = votes - OLD.voice
+    WHERE id = OLD.thread;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS delete_votes ON "vote";
+CREATE TRIGGER delete_votes
+    AFTER DELETE
+    ON "vote"
+    FOR EACH ROW
+EXECUTE PROCEDURE delete_votes();
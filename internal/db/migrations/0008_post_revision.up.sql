@@ -0,0 +1,9 @@
+-- Previous messages of edited posts, one row per edit.
+CREATE UNLOGGED TABLE IF NOT EXISTS "post_revision" (
+    post     int NOT NULL REFERENCES "post" (id) ON DELETE CASCADE,
+    revision int NOT NULL,
+    message  text NOT NULL,
+    editor   citext NOT NULL DEFAULT '',
+    created  timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (post, revision)
+);
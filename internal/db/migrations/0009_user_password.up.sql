@@ -0,0 +1,2 @@
+-- bcrypt hash of the user's password, empty while none is set.
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS password_hash text NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'admin'));
+
+CREATE UNLOGGED TABLE IF NOT EXISTS "forum_moderator" (
+    forum    citext NOT NULL REFERENCES "forum" (slug) ON DELETE CASCADE,
+    nickname citext NOT NULL REFERENCES "user" (nickname) ON DELETE CASCADE,
+    PRIMARY KEY (forum, nickname)
+);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS thread_votes_event ON "thread";
+DROP TRIGGER IF EXISTS post_updated_event ON "post";
+DROP TRIGGER IF EXISTS post_created_event ON "post";
+DROP FUNCTION IF EXISTS thread_votes_event();
+DROP FUNCTION IF EXISTS post_updated_event();
+DROP FUNCTION IF EXISTS post_created_event();
+DROP FUNCTION IF EXISTS emit_event(text, text, int, int, int);
+DROP TABLE IF EXISTS "event";
@@ -0,0 +1,71 @@
+-- Log of changes streamed to clients. Rows are appended by triggers, each
+-- followed by a NOTIFY on the "event" channel carrying the new id, and are
+-- pruned once older than the retention of the stream hub.
+CREATE UNLOGGED TABLE IF NOT EXISTS "event" (
+    id      bigserial PRIMARY KEY,
+    type    text NOT NULL,
+    forum   citext NOT NULL,
+    thread  int NOT NULL,
+    post    int,
+    votes   int,
+    created timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS index_event_thread ON "event" (thread, id);
+CREATE INDEX IF NOT EXISTS index_event_forum ON "event" (forum, id);
+CREATE INDEX IF NOT EXISTS index_event_created ON "event" (created);
+
+CREATE OR REPLACE FUNCTION emit_event(text, text, int, int, int) RETURNS void AS $$
+DECLARE
+    event_id bigint;
+BEGIN
+    INSERT INTO "event" (type, forum, thread, post, votes)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING id INTO event_id;
+    PERFORM pg_notify('event', event_id::text);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION post_created_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM emit_event('post.created', NEW.forum, NEW.thread, NEW.id, NULL);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS post_created_event ON "post";
+CREATE TRIGGER post_created_event
+    AFTER INSERT
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE post_created_event();
+
+CREATE OR REPLACE FUNCTION post_updated_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM emit_event('post.updated', NEW.forum, NEW.thread, NEW.id, NULL);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS post_updated_event ON "post";
+CREATE TRIGGER post_updated_event
+    AFTER UPDATE OF message, isDeleted
+    ON "post"
+    FOR EACH ROW
+    WHEN (OLD.message IS DISTINCT FROM NEW.message OR OLD.isDeleted IS DISTINCT FROM NEW.isDeleted)
+EXECUTE PROCEDURE post_updated_event();
+
+CREATE OR REPLACE FUNCTION thread_votes_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM emit_event('thread.votes', NEW.forum, NEW.id, NULL, NEW.votes);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_votes_event ON "thread";
+CREATE TRIGGER thread_votes_event
+    AFTER UPDATE OF votes
+    ON "thread"
+    FOR EACH ROW
+    WHEN (OLD.votes IS DISTINCT FROM NEW.votes)
+EXECUTE PROCEDURE thread_votes_event();
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "webhook_cursor";
+DROP TABLE IF EXISTS "webhook_attempt";
+DROP TABLE IF EXISTS "webhook_delivery";
+DROP TABLE IF EXISTS "webhook";
+DROP TRIGGER IF EXISTS thread_created_event ON "thread";
+DROP FUNCTION IF EXISTS thread_created_event();
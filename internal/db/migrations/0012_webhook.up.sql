@@ -0,0 +1,57 @@
+CREATE OR REPLACE FUNCTION thread_created_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM emit_event('thread.created', NEW.forum, NEW.id, NULL, NULL);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_created_event ON "thread";
+CREATE TRIGGER thread_created_event
+    AFTER INSERT
+    ON "thread"
+    FOR EACH ROW
+EXECUTE PROCEDURE thread_created_event();
+
+CREATE UNLOGGED TABLE IF NOT EXISTS "webhook" (
+    id      serial PRIMARY KEY,
+    forum   citext NOT NULL REFERENCES "forum" (slug) ON DELETE CASCADE,
+    url     text NOT NULL,
+    secret  text NOT NULL,
+    events  text[] NOT NULL,
+    created timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS index_webhook_forum ON "webhook" (forum);
+
+-- One row per event sent to a webhook. The payload is kept so redeliveries
+-- send the same body.
+CREATE UNLOGGED TABLE IF NOT EXISTS "webhook_delivery" (
+    id           bigserial PRIMARY KEY,
+    webhook      int NOT NULL REFERENCES "webhook" (id) ON DELETE CASCADE,
+    event        bigint NOT NULL,
+    type         text NOT NULL,
+    payload      text NOT NULL,
+    status       text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts     int NOT NULL DEFAULT 0,
+    next_attempt timestamptz,
+    created      timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (webhook, event)
+);
+
+CREATE INDEX IF NOT EXISTS index_webhook_delivery_due ON "webhook_delivery" (next_attempt) WHERE status = 'pending';
+
+CREATE UNLOGGED TABLE IF NOT EXISTS "webhook_attempt" (
+    delivery bigint NOT NULL REFERENCES "webhook_delivery" (id) ON DELETE CASCADE,
+    attempt  int NOT NULL,
+    code     int NOT NULL DEFAULT 0,
+    error    text NOT NULL DEFAULT '',
+    duration int NOT NULL,
+    created  timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (delivery, attempt)
+);
+
+-- Id of the last event turned into deliveries.
+CREATE UNLOGGED TABLE IF NOT EXISTS "webhook_cursor" (
+    id    int PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    event bigint NOT NULL
+);
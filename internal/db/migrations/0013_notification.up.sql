@@ -0,0 +1,13 @@
+-- Mentions of users in posts and replies to their posts.
+CREATE UNLOGGED TABLE IF NOT EXISTS "notification" (
+    id       bigserial PRIMARY KEY,
+    nickname citext NOT NULL REFERENCES "user" (nickname) ON DELETE CASCADE,
+    kind     text NOT NULL CHECK (kind IN ('mention', 'reply')),
+    post     int NOT NULL REFERENCES "post" (id) ON DELETE CASCADE,
+    read     bool NOT NULL DEFAULT FALSE,
+    created  timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (nickname, post, kind)
+);
+
+CREATE INDEX IF NOT EXISTS index_notification_nickname ON "notification" (nickname, id);
+CREATE INDEX IF NOT EXISTS index_notification_unread ON "notification" (nickname) WHERE NOT read;
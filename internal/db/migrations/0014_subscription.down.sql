@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "feed_cursor";
+DROP TABLE IF EXISTS "subscription";
@@ -0,0 +1,19 @@
+-- Threads and forums followed by users. since is the last post id when the
+-- subscription was made, older posts are not in the feed.
+CREATE UNLOGGED TABLE IF NOT EXISTS "subscription" (
+    nickname citext NOT NULL REFERENCES "user" (nickname) ON DELETE CASCADE,
+    thread   int REFERENCES "thread" (id) ON DELETE CASCADE,
+    forum    citext REFERENCES "forum" (slug) ON DELETE CASCADE,
+    since    int NOT NULL DEFAULT 0,
+    created  timestamptz NOT NULL DEFAULT now(),
+    CHECK ((thread IS NULL) <> (forum IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_subscription_thread ON "subscription" (nickname, thread) WHERE thread IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS index_subscription_forum ON "subscription" (nickname, forum) WHERE forum IS NOT NULL;
+
+-- The last post of the feed each user has seen.
+CREATE UNLOGGED TABLE IF NOT EXISTS "feed_cursor" (
+    nickname citext PRIMARY KEY REFERENCES "user" (nickname) ON DELETE CASCADE,
+    seen     int NOT NULL
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "post_vote";
+DROP FUNCTION IF EXISTS make_post_votes();
+DROP FUNCTION IF EXISTS update_post_votes();
+DROP FUNCTION IF EXISTS delete_post_votes();
+DROP INDEX IF EXISTS index_post_thread_score;
+ALTER TABLE "post" DROP COLUMN IF EXISTS score;
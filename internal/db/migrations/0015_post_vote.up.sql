@@ -0,0 +1,60 @@
+ALTER TABLE "post" ADD COLUMN IF NOT EXISTS score int NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS index_post_thread_score ON "post" ("thread", "score" DESC, "id");
+
+CREATE UNLOGGED TABLE IF NOT EXISTS "post_vote" (
+    nickname citext NOT NULL REFERENCES "user" (nickname) ON DELETE CASCADE,
+    post     int NOT NULL REFERENCES "post" (id) ON DELETE CASCADE,
+    voice    int NOT NULL CHECK (voice IN (-1, 1)),
+    PRIMARY KEY (post, nickname)
+);
+
+CREATE INDEX IF NOT EXISTS index_post_vote_nickname ON "post_vote" ("nickname");
+
+CREATE OR REPLACE FUNCTION make_post_votes() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "post"
+    SET score = score + NEW.voice
+    WHERE id = NEW.post;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS insert_post_votes ON "post_vote";
+CREATE TRIGGER insert_post_votes
+    AFTER INSERT
+    ON "post_vote"
+    FOR EACH ROW
+EXECUTE PROCEDURE make_post_votes();
+
+CREATE OR REPLACE FUNCTION update_post_votes() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "post"
+    SET score = score + NEW.voice - OLD.voice
+    WHERE id = NEW.post;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_post_votes ON "post_vote";
+CREATE TRIGGER update_post_votes
+    AFTER UPDATE
+    ON "post_vote"
+    FOR EACH ROW
+EXECUTE PROCEDURE update_post_votes();
+
+CREATE OR REPLACE FUNCTION delete_post_votes() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "post"
+    SET score = score - OLD.voice
+    WHERE id = OLD.post;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS delete_post_votes ON "post_vote";
+CREATE TRIGGER delete_post_votes
+    AFTER DELETE
+    ON "post_vote"
+    FOR EACH ROW
+EXECUTE PROCEDURE delete_post_votes();
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS thread_active ON "post";
+DROP FUNCTION IF EXISTS thread_active();
+DROP TRIGGER IF EXISTS thread_rank ON "thread";
+DROP FUNCTION IF EXISTS thread_rank();
+DROP INDEX IF EXISTS index_thread_forum_hot;
+DROP INDEX IF EXISTS index_thread_forum_active;
+DROP INDEX IF EXISTS index_thread_forum_votes;
+ALTER TABLE "thread" DROP COLUMN IF EXISTS hot;
+ALTER TABLE "thread" DROP COLUMN IF EXISTS active;
+DROP FUNCTION IF EXISTS hot_score(int, timestamptz);
@@ -0,0 +1,53 @@
+-- hot_score ranks threads by votes decayed by age: every tenfold increase in
+-- votes is worth 12.5 hours. The age is measured from a fixed point rather
+-- than from now, so the score never has to be recomputed as time passes.
+CREATE OR REPLACE FUNCTION hot_score(int, timestamptz) RETURNS float8 AS $$
+    SELECT sign($1)::float8 * log(greatest(abs($1), 1)::float8) + extract(epoch FROM $2)::float8 / 45000;
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE "thread" ADD COLUMN IF NOT EXISTS active timestamptz NOT NULL DEFAULT now();
+ALTER TABLE "thread" ADD COLUMN IF NOT EXISTS hot float8 NOT NULL DEFAULT 0;
+
+UPDATE "thread" t SET active = greatest(t.created, (SELECT max(p.created) FROM "post" p WHERE p.thread = t.id)),
+    hot = hot_score(t.votes, t.created);
+
+CREATE INDEX IF NOT EXISTS index_thread_forum_votes ON "thread" ("forum", "votes", "id");
+CREATE INDEX IF NOT EXISTS index_thread_forum_active ON "thread" ("forum", "active", "id");
+CREATE INDEX IF NOT EXISTS index_thread_forum_hot ON "thread" ("forum", "hot", "id");
+
+CREATE OR REPLACE FUNCTION thread_rank() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.active := NEW.created;
+    END IF;
+    NEW.hot := hot_score(NEW.votes, NEW.created);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_rank ON "thread";
+CREATE TRIGGER thread_rank
+    BEFORE INSERT OR UPDATE OF votes
+    ON "thread"
+    FOR EACH ROW
+EXECUTE PROCEDURE thread_rank();
+
+-- Posts are inserted in batches, so the latest post of each thread is taken
+-- once per statement.
+CREATE OR REPLACE FUNCTION thread_active() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE "thread" t
+    SET active = p.created
+    FROM (SELECT thread, max(created) AS created FROM new_posts GROUP BY thread) p
+    WHERE t.id = p.thread AND t.active < p.created;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_active ON "post";
+CREATE TRIGGER thread_active
+    AFTER INSERT
+    ON "post"
+    REFERENCING NEW TABLE AS new_posts
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE thread_active();
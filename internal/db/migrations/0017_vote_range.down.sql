@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS index_vote_thread_nickname;
+ALTER TABLE "forum" DROP CONSTRAINT IF EXISTS forum_vote_range;
+ALTER TABLE "forum" DROP COLUMN IF EXISTS vote_max;
+ALTER TABLE "forum" DROP COLUMN IF EXISTS vote_min;
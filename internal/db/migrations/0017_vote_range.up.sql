@@ -0,0 +1,6 @@
+ALTER TABLE "forum" ADD COLUMN IF NOT EXISTS vote_min int NOT NULL DEFAULT -1;
+ALTER TABLE "forum" ADD COLUMN IF NOT EXISTS vote_max int NOT NULL DEFAULT 1;
+ALTER TABLE "forum" DROP CONSTRAINT IF EXISTS forum_vote_range;
+ALTER TABLE "forum" ADD CONSTRAINT forum_vote_range CHECK (vote_min <= vote_max);
+
+CREATE INDEX IF NOT EXISTS index_vote_thread_nickname ON "vote" ("thread", "nickname");
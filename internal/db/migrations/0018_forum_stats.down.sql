@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS thread_forum_stats ON "thread";
+DROP TRIGGER IF EXISTS update_forum_stats ON "post";
+DROP TRIGGER IF EXISTS make_forum_stats ON "post";
+DROP FUNCTION IF EXISTS thread_forum_stats();
+DROP FUNCTION IF EXISTS update_forum_stats();
+DROP FUNCTION IF EXISTS make_forum_stats();
+DROP FUNCTION IF EXISTS count_forum_post(citext, citext, timestamptz, int);
+DROP FUNCTION IF EXISTS rebuild_forum_stats();
+DROP TABLE IF EXISTS "forum_poster";
+DROP TABLE IF EXISTS "forum_activity";
+DROP FUNCTION IF EXISTS stats_hour(timestamptz);
@@ -0,0 +1,113 @@
+-- Aggregates behind the forum stats: hourly post and thread counts per forum
+-- and hourly post counts per forum and author. Like forum.posts they leave
+-- out deleted posts.
+CREATE UNLOGGED TABLE IF NOT EXISTS "forum_activity" (
+    forum   citext NOT NULL REFERENCES "forum" (slug) ON DELETE CASCADE,
+    hour    timestamptz NOT NULL,
+    posts   int NOT NULL DEFAULT 0,
+    threads int NOT NULL DEFAULT 0,
+    PRIMARY KEY (forum, hour)
+);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS "forum_poster" (
+    forum    citext NOT NULL REFERENCES "forum" (slug) ON DELETE CASCADE,
+    nickname citext NOT NULL REFERENCES "user" (nickname) ON DELETE CASCADE,
+    hour     timestamptz NOT NULL,
+    posts    int NOT NULL DEFAULT 0,
+    PRIMARY KEY (forum, hour, nickname)
+);
+
+CREATE OR REPLACE FUNCTION stats_hour(timestamptz) RETURNS timestamptz AS $$
+    SELECT date_trunc('hour', $1 AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Like every table the aggregates are unlogged, so a crash empties them
+-- together with the posts and threads they count. rebuild_forum_stats
+-- recomputes them; the server runs it at startup when they disagree with the
+-- forum counters.
+CREATE OR REPLACE FUNCTION rebuild_forum_stats() RETURNS void AS $$
+BEGIN
+    TRUNCATE "forum_activity", "forum_poster";
+
+    INSERT INTO "forum_activity" (forum, hour, posts)
+    SELECT forum::citext, stats_hour(created), count(*) FROM "post" WHERE NOT isDeleted GROUP BY 1, 2;
+
+    INSERT INTO "forum_activity" (forum, hour, threads)
+    SELECT forum::citext, stats_hour(created), count(*) FROM "thread" GROUP BY 1, 2
+    ON CONFLICT (forum, hour) DO UPDATE SET threads = EXCLUDED.threads;
+
+    INSERT INTO "forum_poster" (forum, nickname, hour, posts)
+    SELECT forum::citext, author::citext, stats_hour(created), count(*) FROM "post" WHERE NOT isDeleted GROUP BY 1, 2, 3;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT rebuild_forum_stats();
+
+CREATE OR REPLACE FUNCTION count_forum_post(_forum citext, _author citext, _created timestamptz, _delta int) RETURNS void AS $$
+BEGIN
+    INSERT INTO "forum_activity" (forum, hour, posts) VALUES (_forum, stats_hour(_created), _delta)
+    ON CONFLICT (forum, hour) DO UPDATE SET posts = "forum_activity".posts + EXCLUDED.posts;
+    INSERT INTO "forum_poster" (forum, nickname, hour, posts) VALUES (_forum, _author, stats_hour(_created), _delta)
+    ON CONFLICT (forum, hour, nickname) DO UPDATE SET posts = "forum_poster".posts + EXCLUDED.posts;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Posts are inserted in batches, so new posts are counted once per statement.
+CREATE OR REPLACE FUNCTION make_forum_stats() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO "forum_activity" (forum, hour, posts)
+    SELECT forum::citext, stats_hour(created), count(*) FROM new_posts WHERE NOT isDeleted GROUP BY 1, 2
+    ON CONFLICT (forum, hour) DO UPDATE SET posts = "forum_activity".posts + EXCLUDED.posts;
+    INSERT INTO "forum_poster" (forum, nickname, hour, posts)
+    SELECT forum::citext, author::citext, stats_hour(created), count(*) FROM new_posts WHERE NOT isDeleted GROUP BY 1, 2, 3
+    ON CONFLICT (forum, hour, nickname) DO UPDATE SET posts = "forum_poster".posts + EXCLUDED.posts;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS make_forum_stats ON "post";
+CREATE TRIGGER make_forum_stats
+    AFTER INSERT
+    ON "post"
+    REFERENCING NEW TABLE AS new_posts
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE make_forum_stats();
+
+-- Deleting, restoring and re-attributing a post moves its count.
+CREATE OR REPLACE FUNCTION update_forum_stats() RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT OLD.isDeleted THEN
+        PERFORM count_forum_post(OLD.forum::citext, OLD.author::citext, OLD.created, -1);
+    END IF;
+    IF TG_OP = 'UPDATE' AND NOT NEW.isDeleted THEN
+        PERFORM count_forum_post(NEW.forum::citext, NEW.author::citext, NEW.created, 1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_forum_stats ON "post";
+CREATE TRIGGER update_forum_stats
+    AFTER UPDATE OF isDeleted, author OR DELETE
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE update_forum_stats();
+
+CREATE OR REPLACE FUNCTION thread_forum_stats() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO "forum_activity" (forum, hour, threads) VALUES (NEW.forum::citext, stats_hour(NEW.created), 1)
+        ON CONFLICT (forum, hour) DO UPDATE SET threads = "forum_activity".threads + 1;
+    ELSE
+        UPDATE "forum_activity" SET threads = threads - 1 WHERE forum = OLD.forum::citext AND hour = stats_hour(OLD.created);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS thread_forum_stats ON "thread";
+CREATE TRIGGER thread_forum_stats
+    AFTER INSERT OR DELETE
+    ON "thread"
+    FOR EACH ROW
+EXECUTE PROCEDURE thread_forum_stats();
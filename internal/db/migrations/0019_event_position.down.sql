@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS index_event_forum;
+DROP INDEX IF EXISTS index_event_thread;
+DROP INDEX IF EXISTS index_event_position;
+CREATE INDEX IF NOT EXISTS index_event_thread ON "event" (thread, id);
+CREATE INDEX IF NOT EXISTS index_event_forum ON "event" (forum, id);
+ALTER TABLE "event" DROP COLUMN IF EXISTS txid;
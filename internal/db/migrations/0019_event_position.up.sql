@@ -0,0 +1,12 @@
+-- Event ids are taken when a row is inserted, not when its transaction
+-- commits, so a reader following ids skips events committed late. Readers
+-- follow (txid, id) instead and only read the events of transactions older
+-- than every running one, which can't add events any more.
+ALTER TABLE "event" ADD COLUMN IF NOT EXISTS txid bigint NOT NULL DEFAULT 0;
+ALTER TABLE "event" ALTER COLUMN txid SET DEFAULT txid_current();
+
+DROP INDEX IF EXISTS index_event_thread;
+DROP INDEX IF EXISTS index_event_forum;
+CREATE INDEX IF NOT EXISTS index_event_position ON "event" (txid, id);
+CREATE INDEX IF NOT EXISTS index_event_thread ON "event" (thread, txid, id);
+CREATE INDEX IF NOT EXISTS index_event_forum ON "event" (forum, txid, id);
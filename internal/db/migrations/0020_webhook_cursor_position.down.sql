@@ -0,0 +1 @@
+ALTER TABLE "webhook_cursor" DROP COLUMN IF EXISTS txid;
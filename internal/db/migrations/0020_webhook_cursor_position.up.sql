@@ -0,0 +1,3 @@
+-- The webhook cursor follows the event log by position like the stream. An
+-- id kept from before stays a valid position: older events have txid 0.
+ALTER TABLE "webhook_cursor" ADD COLUMN IF NOT EXISTS txid bigint NOT NULL DEFAULT 0;
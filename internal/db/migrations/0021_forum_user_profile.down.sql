@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS update_forum_user ON "user";
+DROP FUNCTION IF EXISTS update_forum_user();
+
+CREATE OR REPLACE FUNCTION add_forum_user() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO forum_user (forum, nickname)
+     VALUES (NEW.forum, NEW.author)
+     ON conflict do nothing;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "forum_user" DROP COLUMN IF EXISTS email;
+ALTER TABLE "forum_user" DROP COLUMN IF EXISTS about;
+ALTER TABLE "forum_user" DROP COLUMN IF EXISTS fullname;
@@ -0,0 +1,38 @@
+-- Profile columns are denormalized so forum user listings need no join.
+ALTER TABLE "forum_user" ADD COLUMN IF NOT EXISTS fullname text NOT NULL DEFAULT '';
+ALTER TABLE "forum_user" ADD COLUMN IF NOT EXISTS about text NOT NULL DEFAULT '';
+ALTER TABLE "forum_user" ADD COLUMN IF NOT EXISTS email citext NOT NULL DEFAULT '';
+
+UPDATE "forum_user" fu
+SET fullname = u.fullname, about = u.about, email = u.email
+FROM "user" u
+WHERE fu.nickname = u.nickname AND fu.email = '';
+
+CREATE OR REPLACE FUNCTION add_forum_user() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO forum_user (forum, nickname, fullname, about, email)
+    SELECT NEW.forum, u.nickname, u.fullname, u.about, u.email
+    FROM "user" u
+    WHERE u.nickname = NEW.author
+    ON CONFLICT DO NOTHING;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_forum_user() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE forum_user
+    SET fullname = NEW.fullname, about = NEW.about, email = NEW.email
+    WHERE nickname = NEW.nickname;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_forum_user ON "user";
+CREATE TRIGGER update_forum_user
+    AFTER UPDATE
+    ON "user"
+    FOR EACH ROW
+EXECUTE PROCEDURE update_forum_user();
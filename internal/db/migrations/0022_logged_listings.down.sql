@@ -0,0 +1,5 @@
+ALTER TABLE "post" SET UNLOGGED;
+ALTER TABLE "thread" SET UNLOGGED;
+ALTER TABLE "forum_user" SET UNLOGGED;
+ALTER TABLE "forum" SET UNLOGGED;
+ALTER TABLE "user" SET UNLOGGED;
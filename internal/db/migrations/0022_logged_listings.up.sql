@@ -0,0 +1,8 @@
+-- Replicas serve the listings, and a standby can't read unlogged tables, so
+-- the tables behind them are written to the WAL. Referenced tables go first:
+-- a logged table can't reference an unlogged one.
+ALTER TABLE "user" SET LOGGED;
+ALTER TABLE "forum" SET LOGGED;
+ALTER TABLE "forum_user" SET LOGGED;
+ALTER TABLE "thread" SET LOGGED;
+ALTER TABLE "post" SET LOGGED;
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+)
+
+// testDatabaseEnv names a scratch database for TestRoundTrip. The test
+// migrates it all the way down, so never point it at data you want to keep.
+const testDatabaseEnv = "MIGRATIONS_TEST_DATABASE_URL"
+
+func TestLoad(t *testing.T) {
+	migrations, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, migration := range migrations {
+		if want := int64(i + 1); migration.Version != want {
+			t.Errorf("migration %04d_%s: want version %d, versions must be consecutive", migration.Version, migration.Name, want)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %04d_%s lacks a script", migration.Version, migration.Name)
+		}
+	}
+}
+
+// TestRoundTrip reverts and re-applies every migration, one at a time from
+// the newest, and then the whole set, so each down script must leave the
+// schema its up script started from.
+func TestRoundTrip(t *testing.T) {
+	url := os.Getenv(testDatabaseEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := len(migrator.migrations)
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := all - 1; i >= 0; i-- {
+		want := migrator.migrations[i].Version
+
+		reverted, err := migrator.Down(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reverted) != 1 || reverted[0].Version != want {
+			t.Fatalf("down reverted %v, want migration %d", versions(reverted), want)
+		}
+
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(applied) != 1 || applied[0].Version != want {
+			t.Fatalf("up applied %v, want migration %d", versions(applied), want)
+		}
+
+		if _, err := migrator.Down(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Fatalf("migration %04d_%s is still applied after reverting all", status.Version, status.Name)
+		}
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != all {
+		t.Fatalf("up applied %d migrations on an empty schema, want %d", len(applied), all)
+	}
+}
+
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, 0, len(migrations))
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
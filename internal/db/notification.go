@@ -0,0 +1,102 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// INSERT
+	qCreateNotification = "INSERT INTO \"notification\" (nickname, kind, post) SELECT u.nickname, $2, $3 FROM \"user\" u WHERE u.nickname = $1 ON CONFLICT (nickname, post, kind) DO NOTHING;"
+
+	// SELECT
+	qGetNotifications = "SELECT n.id, n.nickname, n.kind, n.read, n.created, " +
+		"p.id, p.parent, p.author, p.message, p.isEdited, p.forum, p.thread, p.created, p.isDeleted, p.score " +
+		"FROM \"notification\" n JOIN \"post\" p ON p.id = n.post " +
+		"WHERE n.nickname = $1 AND (NOT $2 OR NOT n.read) AND ($3 = 0 OR n.id < $3) ORDER BY n.id DESC LIMIT $4;"
+	qCountUnread = "SELECT count(*) FROM \"notification\" WHERE nickname = $1 AND NOT read;"
+
+	// UPDATE
+	qMarkRead = "UPDATE \"notification\" SET read = TRUE WHERE nickname = $1 AND NOT read AND (cardinality($2::bigint[]) = 0 OR id = ANY($2));"
+)
+
+type NotificationRepository interface {
+	// CreateNotifications stores notifications of existing users, skipping
+	// those already stored.
+	CreateNotifications(ctx context.Context, notifications []*core.Notification) error
+	// GetNotifications returns the notifications of a user newest first,
+	// starting below the id before when it is set.
+	GetNotifications(ctx context.Context, nickname string, unread bool, before int64, limit int64) ([]*core.Notification, error)
+	CountUnread(ctx context.Context, nickname string) (int64, error)
+	// MarkRead marks the notifications ids of a user, or all of them when ids
+	// is empty, as read and returns how many were unread.
+	MarkRead(ctx context.Context, nickname string, ids []int64) (int64, error)
+}
+
+type notificationRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *notificationRepositoryImpl) CreateNotifications(ctx context.Context, notifications []*core.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, n := range notifications {
+		batch.Queue(qCreateNotification, n.Nickname, n.Kind, n.PostID)
+	}
+	res := repo.db.SendBatch(ctx, batch)
+	defer res.Close()
+	for range notifications {
+		if _, err := res.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *notificationRepositoryImpl) GetNotifications(ctx context.Context, nickname string, unread bool, before int64, limit int64) ([]*core.Notification, error) {
+	rows, err := repo.db.Query(ctx, qGetNotifications, nickname, unread, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]*core.Notification, 0)
+	for rows.Next() {
+		n := &core.Notification{Post: &core.Post{}}
+		p := n.Post
+		if err := rows.Scan(&n.ID, &n.Nickname, &n.Kind, &n.Read, &n.Created,
+			&p.ID, &p.Pred, &p.Author, &p.Message, &p.IsEdited, &p.Forum, &p.Thread, &p.Created, &p.IsDeleted, &p.Score); err != nil {
+			return nil, err
+		}
+		p.Tombstone()
+		n.PostID = p.ID
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (repo *notificationRepositoryImpl) CountUnread(ctx context.Context, nickname string) (int64, error) {
+	var count int64
+	err := repo.db.QueryRow(ctx, qCountUnread, nickname).Scan(&count)
+	return count, err
+}
+
+func (repo *notificationRepositoryImpl) MarkRead(ctx context.Context, nickname string, ids []int64) (int64, error) {
+	if ids == nil {
+		ids = []int64{}
+	}
+	res, err := repo.db.Exec(ctx, qMarkRead, nickname, ids)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *notificationRepositoryImpl {
+	return &notificationRepositoryImpl{db: db}
+}
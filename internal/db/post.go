@@ -17,77 +17,153 @@ const (
 	qTemplateCreatePost = "INSERT INTO \"post\" (parent, author, message, forum, thread, created) VALUES "
 
 	// SELECT
-	qCheckPred = "SELECT \"thread\" FROM \"post\" WHERE id = $1;"
-	qGetPost   = "SELECT id, parent, author, message, isEdited, forum, thread, created FROM \"post\" WHERE id = $1;"
+	qGetPostThreads   = "SELECT id, thread FROM \"post\" WHERE id = ANY($1);"
+	qGetPostRevisions = "SELECT post, revision, message, editor, created FROM \"post_revision\" WHERE post = $1 ORDER BY revision;"
+	qGetPostRevision  = "SELECT post, revision, message, editor, created FROM \"post_revision\" WHERE post = $1 AND revision = $2;"
+	qGetPost          = "SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM \"post\" WHERE id = $1;"
 
 	// UPDATE
-	qPostUpdate = "UPDATE \"post\" SET message = $2, isEdited = true WHERE id = $1 RETURNING id, parent, author, message, isEdited, forum, thread, created;"
+	qPostLock           = "SELECT message FROM \"post\" WHERE id = $1 FOR UPDATE;"
+	qPostRevisionInsert = "INSERT INTO \"post_revision\" (post, revision, message, editor) " +
+		"SELECT $1, COALESCE(max(revision), 0) + 1, $2, $3 FROM \"post_revision\" WHERE post = $1;"
+	qPostUpdate     = "UPDATE \"post\" SET message = $2, isEdited = true WHERE id = $1 RETURNING id, parent, author, message, isEdited, forum, thread, created, isDeleted, score;"
+	qPostSetDeleted = "UPDATE \"post\" SET isDeleted = $2 WHERE id = $1 RETURNING id, parent, author, message, isEdited, forum, thread, created, isDeleted, score;"
+
+	// DELETE
+	qDeletePostTree = "DELETE FROM \"post\" WHERE path[1] = (SELECT path[1] FROM \"post\" WHERE id = $1) AND path @> ARRAY[$1]::int[];"
 )
 
 type PostRepository interface {
+	// CreatePost inserts the posts in one transaction, all or none of them.
 	CreatePost(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error)
-	CheckPredPost(ctx context.Context, parent int) (int, error)
-
-	GetPost(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
-	GetPostTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
-	GetPostPredTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
+	// GetPostThreads maps the ids of the posts which exist to their thread.
+	GetPostThreads(ctx context.Context, ids []int64) (map[int64]int64, error)
+
+	GetPost(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error)
+	GetPostTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error)
+	GetPostPredTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error)
+	// GetPostTop orders posts by score, highest first, and then by id.
+	GetPostTop(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error)
 	GetPostDetails(ctx context.Context, id int64, related string) (*dto.PostInfo, error)
 	GetPostByID(ctx context.Context, id int64) (*core.Post, error)
 
-	UpdatePost(ctx context.Context, id int64, message string) (*core.Post, error)
+	GetPostRevisions(ctx context.Context, id int64) ([]*core.PostRevision, error)
+	GetPostRevision(ctx context.Context, id int64, revision int64) (*core.PostRevision, error)
+
+	UpdatePost(ctx context.Context, id int64, message string, editor string) (*core.Post, error)
+	DeletePost(ctx context.Context, id int64) (*core.Post, error)
+	RestorePost(ctx context.Context, id int64) (*core.Post, error)
+	DeletePostTree(ctx context.Context, id int64) (int64, error)
 }
 
 type postRepositoryImpl struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	router *Router
 }
 
-func (repo *postRepositoryImpl) CreatePost(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
-	query := strings.Builder{}
-	query.WriteString(qTemplateCreatePost)
+const (
+	createPostArgs = 6
+	// createPostChunk keeps one INSERT under the limit of 65535 parameters.
+	createPostChunk = 65535 / createPostArgs
+)
 
-	queryArgs := make([]interface{}, 0, len(posts))
+// CreatePost inserts the posts with one multi-row INSERT per chunk, so the
+// statement-level triggers on post run once per chunk, and sends all of them
+// in one batch.
+func (repo *postRepositoryImpl) CreatePost(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
 	newPosts := make([]*core.Post, 0, len(posts))
 	insertTime := time.Unix(0, time.Now().UnixNano()/1e6*1e6)
-	for i, post := range posts {
-		p := &core.Post{Pred: post.Parent, Author: post.Author, Message: post.Message, Forum: forum, Thread: thread, Created: insertTime}
-		newPosts = append(newPosts, p)
-		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d),", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6)
-		queryArgs = append(queryArgs, post.Parent, post.Author, post.Message, forum, thread, insertTime)
+	for _, post := range posts {
+		newPosts = append(newPosts, &core.Post{Pred: post.Parent, Author: post.Author, Message: post.Message, Forum: forum, Thread: thread, Created: insertTime})
+	}
+
+	batch := &pgx.Batch{}
+	for start := 0; start < len(newPosts); start += createPostChunk {
+		chunk := newPosts[start:]
+		if len(chunk) > createPostChunk {
+			chunk = chunk[:createPostChunk]
+		}
+
+		query := strings.Builder{}
+		query.WriteString(qTemplateCreatePost)
+		args := make([]interface{}, 0, len(chunk)*createPostArgs)
+		for i, p := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6)
+			args = append(args, p.Pred, p.Author, p.Message, forum, thread, insertTime)
+		}
+		query.WriteString(" RETURNING id;")
+		batch.Queue(query.String(), args...)
 	}
 
-	qs := query.String()
-	qs = qs[:len(qs)-1]
-	qs += " RETURNING id;"
+	err := repo.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
 
-	rows, err := repo.db.Query(ctx, qs, queryArgs...)
+		for start := 0; start < len(newPosts); start += createPostChunk {
+			rows, err := results.Query()
+			if err != nil {
+				return err
+			}
+			i := start
+			for rows.Next() {
+				if err := rows.Scan(&newPosts[i].ID); err != nil {
+					rows.Close()
+					return err
+				}
+				i++
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPosts, nil
+}
+
+func (repo *postRepositoryImpl) GetPostThreads(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	rows, err := repo.db.Query(ctx, qGetPostThreads, ids)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	for i := 0; rows.Next(); i++ {
-		if err = rows.Scan(&newPosts[i].ID); err != nil {
+	threads := make(map[int64]int64, len(ids))
+	for rows.Next() {
+		var id, thread int64
+		if err := rows.Scan(&id, &thread); err != nil {
 			return nil, err
 		}
+		threads[id] = thread
 	}
-
-	return newPosts, nil
+	return threads, rows.Err()
 }
 
-func (repo *postRepositoryImpl) CheckPredPost(ctx context.Context, parent int) (int, error) {
-	var threadID int
-	err := repo.db.QueryRow(ctx, qCheckPred, parent).Scan(&threadID)
-	return threadID, wrapErr(err)
-}
+func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	conn := repo.router.Read(ctx)
+	query := "SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM \"post\" WHERE thread = $1 "
+	args := []interface{}{id}
 
-func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
-	query := "SELECT id, parent, author, message, isEdited, forum, thread, created FROM \"post\" WHERE thread = $1 "
-
-	if since != -1 {
+	// Cursors issued by this service carry the full (created, id) sort key;
+	// the legacy since parameter only knows the post id.
+	if after != nil {
+		op := ">"
 		if desc {
-			query += "AND id < $2 "
+			op = "<"
+		}
+		if after.Created.IsZero() {
+			query += fmt.Sprintf("AND id %s $2 ", op)
+			args = append(args, after.ID)
 		} else {
-			query += "AND id > $2 "
+			query += fmt.Sprintf("AND (created, id) %s ($2, $3) ", op)
+			args = append(args, after.Created, after.ID)
 		}
 	}
 
@@ -99,13 +175,7 @@ func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, since int64
 
 	query += fmt.Sprintf("LIMIT %d ", limit)
 
-	var rows pgx.Rows
-	var err error
-	if since == -1 {
-		rows, err = repo.db.Query(ctx, query, id)
-	} else {
-		rows, err = repo.db.Query(ctx, query, id, since)
-	}
+	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -113,8 +183,8 @@ func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, since int64
 
 	posts := make([]*core.Post, 0, rows.CommandTag().RowsAffected())
 	for rows.Next() {
-		post := &core.Post{}
-		if err := rows.Scan(&post.ID, &post.Pred, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
 		posts = append(posts, post)
@@ -123,16 +193,20 @@ func (repo *postRepositoryImpl) GetPost(ctx context.Context, id int, since int64
 	return posts, err
 }
 
-func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
-	query := "SELECT id, parent, author, message, isEdited, forum, thread, created FROM \"post\" WHERE thread = $1 "
+func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	conn := repo.router.Read(ctx)
+	query := "SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM \"post\" WHERE thread = $1 "
+	args := []interface{}{id}
 
-	if since != -1 {
+	// Paths are unique and never change, so the path of the last post is a stable position.
+	if after != nil {
 		if desc {
 			query += "and path < "
 		} else {
 			query += "and path > "
 		}
-		query += fmt.Sprintf("(SELECT path FROM \"post\" WHERE id = %d) ", since)
+		query += "(SELECT path FROM \"post\" WHERE id = $2) "
+		args = append(args, after.ID)
 	}
 
 	if desc {
@@ -143,7 +217,7 @@ func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, since i
 
 	query += fmt.Sprintf("LIMIT NULLIF(%d, 0) ", limit)
 
-	rows, err := repo.db.Query(ctx, query, id)
+	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -151,8 +225,8 @@ func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, since i
 
 	posts := make([]*core.Post, 0, rows.CommandTag().RowsAffected())
 	for rows.Next() {
-		post := &core.Post{}
-		if err := rows.Scan(&post.ID, &post.Pred, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
 		posts = append(posts, post)
@@ -161,37 +235,38 @@ func (repo *postRepositoryImpl) GetPostTree(ctx context.Context, id int, since i
 	return posts, nil
 }
 
-func (repo *postRepositoryImpl) GetPostPredTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
+func (repo *postRepositoryImpl) GetPostPredTree(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	conn := repo.router.Read(ctx)
 	var rows pgx.Rows
 	var err error
-	if since == -1 {
+	if after == nil {
 		if desc {
-			rows, err = repo.db.Query(ctx,
-				` SELECT id, parent, author, message, isEdited, forum, thread, created FROM "post"
+			rows, err = conn.Query(ctx,
+				` SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM "post"
 					WHERE path[1] IN (SELECT id FROM "post" WHERE thread = $1 AND parent = 0 ORDER BY id DESC LIMIT $2)
 					ORDER BY path[1] DESC, path ASC, id ASC;`,
 				id, limit)
 		} else {
-			rows, err = repo.db.Query(ctx,
-				`	SELECT id, parent, author, message, isEdited, forum, thread, created FROM "post"
+			rows, err = conn.Query(ctx,
+				`	SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM "post"
 					WHERE path[1] IN (SELECT id FROM "post" WHERE thread = $1 AND parent = 0 ORDER BY id ASC LIMIT $2)
 					ORDER BY path ASC, id ASC;`,
 				id, limit)
 		}
 	} else {
 		if desc {
-			rows, err = repo.db.Query(ctx,
-				` SELECT id, parent, author, message, isEdited, forum, thread, created FROM "post"
+			rows, err = conn.Query(ctx,
+				` SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM "post"
 					WHERE path[1] IN (SELECT id FROM "post" WHERE thread = $1 AND parent = 0 AND path[1] < (SELECT path[1] FROM "post" WHERE id = $2)
 					ORDER BY id DESC LIMIT $3) ORDER BY path[1] DESC, path ASC, id ASC;`,
-				id, since, limit)
+				id, after.ID, limit)
 		} else {
-			rows, err = repo.db.Query(ctx,
-				` SELECT id, parent, author, message, isEdited, forum, thread, created FROM "post"
+			rows, err = conn.Query(ctx,
+				` SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM "post"
 					WHERE path[1] IN (SELECT id FROM "post" WHERE thread = $1 AND parent = 0 AND path[1] >
 					(SELECT path[1] FROM "post" WHERE id = $2) ORDER BY id ASC LIMIT $3) 
 					ORDER BY path ASC, id ASC;`,
-				id, since, limit)
+				id, after.ID, limit)
 		}
 	}
 	if err != nil {
@@ -201,8 +276,8 @@ func (repo *postRepositoryImpl) GetPostPredTree(ctx context.Context, id int, sin
 
 	posts := make([]*core.Post, 0, rows.CommandTag().RowsAffected())
 	for rows.Next() {
-		post := &core.Post{}
-		if err := rows.Scan(&post.ID, &post.Pred, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created); err != nil {
+		post, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
 		posts = append(posts, post)
@@ -211,36 +286,74 @@ func (repo *postRepositoryImpl) GetPostPredTree(ctx context.Context, id int, sin
 	return posts, nil
 }
 
+func (repo *postRepositoryImpl) GetPostTop(ctx context.Context, id int, after *core.Cursor, desc bool, limit int64) ([]*core.Post, error) {
+	conn := repo.router.Read(ctx)
+	query := "SELECT id, parent, author, message, isEdited, forum, thread, created, isDeleted, score FROM \"post\" WHERE thread = $1 "
+	args := []interface{}{id}
+
+	if after != nil {
+		if desc {
+			query += "AND (score > $2 OR (score = $2 AND id < $3)) "
+		} else {
+			query += "AND (score < $2 OR (score = $2 AND id > $3)) "
+		}
+		args = append(args, int64(after.Score), after.ID)
+	}
+
+	if desc {
+		query += "ORDER BY score ASC, id DESC "
+	} else {
+		query += "ORDER BY score DESC, id ASC "
+	}
+
+	query += fmt.Sprintf("LIMIT NULLIF(%d, 0) ", limit)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]*core.Post, 0)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
 const (
 	qGetPostAuthor = "SELECT a.nickname, a.fullname, a.about, a.email FROM \"post\" JOIN \"user\" a ON a.nickname = \"post\".author WHERE \"post\".id = $1;"
-	qGetPostThread = "SELECT th.id, th.title, th.author, th.forum, th.message, th.votes, th.slug, th.created FROM \"post\" JOIN \"thread\" th ON th.id = \"post\".thread WHERE \"post\".id = $1;"
-	qGetPostForum  = "SELECT f.title, f.user, f.slug, f.posts, f.threads FROM \"post\" JOIN \"forum\" f ON f.slug = \"post\".forum WHERE \"post\".id = $1;"
+	qGetPostThread = "SELECT th.id, th.title, th.author, th.forum, th.message, th.votes, th.slug, th.created, th.state FROM \"post\" JOIN \"thread\" th ON th.id = \"post\".thread WHERE \"post\".id = $1;"
+	qGetPostForum  = "SELECT f.title, f.user, f.slug, f.posts, f.threads, f.description, f.vote_min, f.vote_max FROM \"post\" JOIN \"forum\" f ON f.slug = \"post\".forum WHERE \"post\".id = $1;"
 )
 
 func (repo *postRepositoryImpl) GetPostDetails(ctx context.Context, id int64, related string) (*dto.PostInfo, error) {
+	conn := repo.router.Read(ctx)
 	postDetails := &dto.PostInfo{}
 	for _, arg := range strings.Split(related, ",") {
 		switch arg {
 		case "user":
 			author := &core.User{}
-			err := repo.db.QueryRow(ctx, qGetPostAuthor, id).Scan(&author.Nickname, &author.FullName, &author.About, &author.Email)
+			err := conn.QueryRow(ctx, qGetPostAuthor, id).Scan(&author.Nickname, &author.FullName, &author.About, &author.Email)
 			if err != nil {
 				return nil, wrapErr(err)
 			}
 
 			postDetails.Author = author
 		case "thread":
-			thread := &core.Thread{}
-			err := repo.db.QueryRow(ctx, qGetPostThread, id).
-				Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum, &thread.Message, &thread.Votes, &thread.Slug, &thread.Created)
+			thread, err := scanThread(conn.QueryRow(ctx, qGetPostThread, id))
 			if err != nil {
 				return nil, wrapErr(err)
 			}
 
 			postDetails.Thread = thread
 		case "forum":
-			forum := &core.Forum{}
-			err := repo.db.QueryRow(ctx, qGetPostForum, id).Scan(&forum.Title, &forum.User, &forum.Slug, &forum.Posts, &forum.Threads)
+			forum, err := scanForum(conn.QueryRow(ctx, qGetPostForum, id))
 			if err != nil {
 				return nil, wrapErr(err)
 			}
@@ -252,23 +365,106 @@ func (repo *postRepositoryImpl) GetPostDetails(ctx context.Context, id int64, re
 }
 
 func (repo *postRepositoryImpl) GetPostByID(ctx context.Context, id int64) (*core.Post, error) {
-	post := &core.Post{}
-	err := repo.db.QueryRow(ctx, qGetPost, id).
-		Scan(&post.ID, &post.Pred, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created)
-	return post, wrapErr(err)
+	post, err := scanPost(repo.db.QueryRow(ctx, qGetPost, id))
+	if err != nil {
+		return &core.Post{}, wrapErr(err)
+	}
+	return post, nil
 }
 
-func (repo *postRepositoryImpl) UpdatePost(ctx context.Context, id int64, message string) (*core.Post, error) {
-	post := &core.Post{}
-	err := repo.db.QueryRow(ctx, qPostUpdate, id, message).
-		Scan(&post.ID, &post.Pred, &post.Author, &post.Message,
-			&post.IsEdited, &post.Forum, &post.Thread, &post.Created)
+func (repo *postRepositoryImpl) GetPostRevisions(ctx context.Context, id int64) ([]*core.PostRevision, error) {
+	rows, err := repo.db.Query(ctx, qGetPostRevisions, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]*core.PostRevision, 0)
+	for rows.Next() {
+		revision, err := scanPostRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (repo *postRepositoryImpl) GetPostRevision(ctx context.Context, id int64, revision int64) (*core.PostRevision, error) {
+	rev, err := scanPostRevision(repo.db.QueryRow(ctx, qGetPostRevision, id, revision))
 	if err != nil {
 		return nil, wrapErr(err)
 	}
+	return rev, nil
+}
+
+// UpdatePost keeps the old message as the next revision. The post row is
+// locked before the revision number is taken, so concurrent edits of one post
+// queue up instead of racing for the same number.
+func (repo *postRepositoryImpl) UpdatePost(ctx context.Context, id int64, message string, editor string) (*core.Post, error) {
+	var post *core.Post
+	err := repo.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var old string
+		if err := tx.QueryRow(ctx, qPostLock, id).Scan(&old); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, qPostRevisionInsert, id, old, editor); err != nil {
+			return err
+		}
+
+		var err error
+		post, err = scanPost(tx.QueryRow(ctx, qPostUpdate, id, message))
+		return err
+	})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return post, nil
+}
+
+func (repo *postRepositoryImpl) DeletePost(ctx context.Context, id int64) (*core.Post, error) {
+	return repo.setDeleted(ctx, id, true)
+}
+
+func (repo *postRepositoryImpl) RestorePost(ctx context.Context, id int64) (*core.Post, error) {
+	return repo.setDeleted(ctx, id, false)
+}
+
+func (repo *postRepositoryImpl) setDeleted(ctx context.Context, id int64, deleted bool) (*core.Post, error) {
+	post, err := scanPost(repo.db.QueryRow(ctx, qPostSetDeleted, id, deleted))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return post, nil
+}
+
+func (repo *postRepositoryImpl) DeletePostTree(ctx context.Context, id int64) (int64, error) {
+	res, err := repo.db.Exec(ctx, qDeletePostTree, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// scanPost reads a row selected with the post columns and hides the content of deleted posts.
+func scanPost(row pgx.Row) (*core.Post, error) {
+	post := &core.Post{}
+	if err := row.Scan(&post.ID, &post.Pred, &post.Author, &post.Message,
+		&post.IsEdited, &post.Forum, &post.Thread, &post.Created, &post.IsDeleted, &post.Score); err != nil {
+		return nil, err
+	}
+	post.Tombstone()
 	return post, nil
 }
 
-func NewPostRepository(db *pgxpool.Pool) (*postRepositoryImpl, error) {
-	return &postRepositoryImpl{db: db}, nil
+func scanPostRevision(row pgx.Row) (*core.PostRevision, error) {
+	rev := &core.PostRevision{}
+	if err := row.Scan(&rev.Post, &rev.Revision, &rev.Message, &rev.Editor, &rev.Created); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+func NewPostRepository(router *Router) (*postRepositoryImpl, error) {
+	return &postRepositoryImpl{db: router.Primary(), router: router}, nil
 }
@@ -1,7 +1,5 @@
 package db
 
-import "github.com/jackc/pgx/v4/pgxpool"
-
 type Repository struct {
 	UserRepo    UserRepository
 	ForumRepo   ForumRepository
@@ -9,25 +7,37 @@ type Repository struct {
 	PostRepo    PostRepository
 	VoteRepo    VoteRepository
 	ServiceRepo ServiceRepository
+	SearchRepo  SearchRepository
+	EventRepo   EventRepository
+	WebhookRepo WebhookRepository
+	NotifyRepo  NotificationRepository
+	SubRepo     SubscriptionRepository
+	StatsRepo   StatsRepository
+
+	// Router is nil for the in-memory storage.
+	Router *Router
 }
 
-func NewRepository(db *pgxpool.Pool) (*Repository, error) {
+// NewRepository creates the repositories on the primary of router; the
+// read-only listings are sent to its replicas.
+func NewRepository(router *Router) (*Repository, error) {
 	var err error
-	repository := new(Repository)
+	repository := &Repository{Router: router}
+	db := router.Primary()
 
 	repository.UserRepo, err = NewUserRepository(db)
 	if err != nil {
 		return nil, err
 	}
 
-	repository.ForumRepo = NewForumRepository(db)
+	repository.ForumRepo = NewForumRepository(router)
 
 	repository.ThreadRepo, err = NewThreadRepository(db)
 	if err != nil {
 		return nil, err
 	}
 
-	repository.PostRepo, err = NewPostRepository(db)
+	repository.PostRepo, err = NewPostRepository(router)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +47,13 @@ func NewRepository(db *pgxpool.Pool) (*Repository, error) {
 		return nil, err
 	}
 
-	repository.ServiceRepo = NewServiceRepository(db)
+	repository.ServiceRepo = NewServiceRepository(router)
+	repository.SearchRepo = NewSearchRepository(db)
+	repository.EventRepo = NewEventRepository(db)
+	repository.WebhookRepo = NewWebhookRepository(db)
+	repository.NotifyRepo = NewNotificationRepository(db)
+	repository.SubRepo = NewSubscriptionRepository(db)
+	repository.StatsRepo = NewStatsRepository(db)
 
 	return repository, nil
 }
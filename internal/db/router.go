@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds one health check of a replica.
+const checkTimeout = time.Second
+
+// qReplayLag is how far a standby trails the primary, in seconds. A standby
+// which has replayed all it received is caught up however old its last
+// transaction is; NULL means the pool is no standby or never replayed.
+const qReplayLag = `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END::float8;`
+
+type sessionKey struct{}
+
+// WithSession tags ctx with the session whose reads must see its own
+// writes, e.g. the signed in user or the client address.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+func sessionFrom(ctx context.Context) string {
+	session, _ := ctx.Value(sessionKey{}).(string)
+	return session
+}
+
+// Router picks the pool a query runs on. Writes go to the primary; reads
+// which tolerate replication lag may go to a healthy replica, except for a
+// session which wrote within the last stickiness. A replica is healthy while
+// it trails the primary by no more than the stickiness, so that a session sees
+// its writes once it leaves the primary.
+type Router struct {
+	primary    *pgxpool.Pool
+	replicas   []*replica
+	next       uint32
+	stickiness time.Duration
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy int32
+}
+
+func (r *Router) Primary() *pgxpool.Pool {
+	return r.primary
+}
+
+// Read returns the pool for a read-only query, the primary when no replica
+// is healthy.
+func (r *Router) Read(ctx context.Context) *pgxpool.Pool {
+	if len(r.replicas) == 0 || r.recentlyWrote(sessionFrom(ctx)) {
+		return r.primary
+	}
+
+	start := atomic.AddUint32(&r.next, 1)
+	for i := range r.replicas {
+		replica := r.replicas[(int(start)+i)%len(r.replicas)]
+		if atomic.LoadInt32(&replica.healthy) == 1 {
+			return replica.pool
+		}
+	}
+	return r.primary
+}
+
+// Wrote records that the session of ctx has written, so that it reads from
+// the primary for a while.
+func (r *Router) Wrote(ctx context.Context) {
+	session := sessionFrom(ctx)
+	if len(r.replicas) == 0 || session == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[session] = time.Now()
+}
+
+func (r *Router) recentlyWrote(session string) bool {
+	if session == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wrote, ok := r.writes[session]
+	return ok && time.Since(wrote) < r.stickiness
+}
+
+// Monitor checks the replay lag of the replicas every interval until ctx is
+// done; a replica only receives reads while it answers and keeps up.
+func (r *Router) Monitor(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Router) check(ctx context.Context) {
+	for _, replica := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		var lag *float64
+		var healthy int32
+		if err := replica.pool.QueryRow(checkCtx, qReplayLag).Scan(&lag); err == nil && lag != nil &&
+			time.Duration(*lag*float64(time.Second)) <= r.stickiness {
+			healthy = 1
+		}
+		cancel()
+		atomic.StoreInt32(&replica.healthy, healthy)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for session, wrote := range r.writes {
+		if time.Since(wrote) >= r.stickiness {
+			delete(r.writes, session)
+		}
+	}
+}
+
+func NewRouter(primary *pgxpool.Pool, replicas []*pgxpool.Pool, stickiness time.Duration) *Router {
+	r := &Router{primary: primary, stickiness: stickiness, writes: make(map[string]time.Time)}
+	for _, pool := range replicas {
+		r.replicas = append(r.replicas, &replica{pool: pool})
+	}
+	return r
+}
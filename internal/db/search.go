@@ -0,0 +1,115 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strings"
+)
+
+const (
+	// SELECT
+	qSearchThreads = `SELECT 'thread' AS kind, t.id, t.id AS thread, t.forum, t.author, t.title, t.message AS body, t.created,
+		ts_rank(t.search, q)::float8 AS rank
+		FROM "thread" t, websearch_to_tsquery('simple', $1) q WHERE t.search @@ q `
+	qSearchPosts = `SELECT 'post' AS kind, p.id, p.thread, p.forum, p.author, '' AS title, p.message AS body, p.created,
+		ts_rank(p.search, q)::float8 AS rank
+		FROM "post" p, websearch_to_tsquery('simple', $1) q WHERE p.search @@ q AND NOT p.isDeleted `
+	qSearchTemplate = `SELECT kind, id, thread, forum, author, title,
+		ts_headline('simple', body, websearch_to_tsquery('simple', $1), 'StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15'),
+		rank, created
+		FROM (%s) hits `
+)
+
+type SearchRepository interface {
+	Search(ctx context.Context, query *core.SearchQuery) ([]*core.SearchHit, error)
+}
+
+type searchRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *searchRepositoryImpl) Search(ctx context.Context, query *core.SearchQuery) ([]*core.SearchHit, error) {
+	args := []interface{}{query.Text}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Placeholders are shared by both halves of the union.
+	var forum, thread, author, since, until string
+	if query.Forum != "" {
+		forum = arg(query.Forum)
+	}
+	if query.Thread != 0 {
+		thread = arg(query.Thread)
+	}
+	if query.Author != "" {
+		author = arg(query.Author)
+	}
+	if !query.Since.IsZero() {
+		since = arg(query.Since)
+	}
+	if !query.Until.IsZero() {
+		until = arg(query.Until)
+	}
+
+	filter := func(alias string, threadColumn string) string {
+		var b strings.Builder
+		if forum != "" {
+			fmt.Fprintf(&b, "AND %s.forum = %s ", alias, forum)
+		}
+		if thread != "" {
+			fmt.Fprintf(&b, "AND %s.%s = %s ", alias, threadColumn, thread)
+		}
+		if author != "" {
+			fmt.Fprintf(&b, "AND %s.author = %s ", alias, author)
+		}
+		if since != "" {
+			fmt.Fprintf(&b, "AND %s.created >= %s ", alias, since)
+		}
+		if until != "" {
+			fmt.Fprintf(&b, "AND %s.created < %s ", alias, until)
+		}
+		return b.String()
+	}
+
+	var parts []string
+	if query.Type != core.SearchHitPost {
+		parts = append(parts, qSearchThreads+filter("t", "id"))
+	}
+	if query.Type != core.SearchHitThread {
+		parts = append(parts, qSearchPosts+filter("p", "thread"))
+	}
+
+	sql := fmt.Sprintf(qSearchTemplate, strings.Join(parts, " UNION ALL "))
+	if query.After != nil {
+		sql += fmt.Sprintf("WHERE (rank, id, kind) < (%s, %s, %s) ", arg(query.After.Score), arg(query.After.ID), arg(query.After.Kind))
+	}
+	sql += "ORDER BY rank DESC, id DESC, kind DESC "
+	if query.Limit > 0 {
+		sql += fmt.Sprintf("LIMIT %d ", query.Limit)
+	}
+
+	rows, err := repo.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]*core.SearchHit, 0)
+	for rows.Next() {
+		h := &core.SearchHit{}
+		if err := rows.Scan(&h.Type, &h.ID, &h.Thread, &h.Forum, &h.Author, &h.Title, &h.Snippet, &h.Rank, &h.Created); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+
+	return hits, rows.Err()
+}
+
+func NewSearchRepository(db *pgxpool.Pool) *searchRepositoryImpl {
+	return &searchRepositoryImpl{db: db}
+}
@@ -8,7 +8,7 @@ import (
 
 const (
 	// TRUNCATE
-	qDeleteTables = "TRUNCATE TABLE \"user\", \"forum\", \"thread\", \"post\", \"forum_user\", \"vote\" CASCADE;"
+	qDeleteTables = "TRUNCATE TABLE \"user\", \"forum\", \"thread\", \"post\", \"forum_user\", \"vote\", \"event\" CASCADE;"
 
 	// SELECT
 	qCountAll = "SELECT (SELECT count(*) FROM \"user\") AS user, (SELECT count(*) FROM \"forum\") AS forum, (SELECT count(*) FROM \"thread\") AS thread, (SELECT count(*) FROM \"post\") AS post;"
@@ -20,12 +20,14 @@ type ServiceRepository interface {
 }
 
 type serviceRepositoryImpl struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	router *Router
 }
 
 func (repo *serviceRepositoryImpl) Status(ctx context.Context) (*core.Service, error) {
+	conn := repo.router.Read(ctx)
 	res := &core.Service{}
-	err := repo.db.QueryRow(ctx, qCountAll).
+	err := conn.QueryRow(ctx, qCountAll).
 		Scan(&res.User, &res.Forum,
 			&res.Thread, &res.Post)
 	return res, err
@@ -36,6 +38,6 @@ func (repo *serviceRepositoryImpl) Delete(ctx context.Context) error {
 	return err
 }
 
-func NewServiceRepository(db *pgxpool.Pool) *serviceRepositoryImpl {
-	return &serviceRepositoryImpl{db: db}
+func NewServiceRepository(router *Router) *serviceRepositoryImpl {
+	return &serviceRepositoryImpl{db: router.Primary(), router: router}
 }
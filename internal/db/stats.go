@@ -0,0 +1,112 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+const (
+	// SELECT
+	qGetForumActivity = `SELECT date_trunc($3, hour AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket, sum(posts), sum(threads) FROM "forum_activity" ` +
+		`WHERE forum = $1 AND hour >= $2 GROUP BY bucket HAVING sum(posts) <> 0 OR sum(threads) <> 0 ORDER BY bucket;`
+	qGetForumPosters = `SELECT nickname, sum(posts) AS posts FROM "forum_poster" WHERE forum = $1 AND hour >= $2 ` +
+		`GROUP BY nickname HAVING sum(posts) > 0 ORDER BY posts DESC, nickname LIMIT $3;`
+	qGetForumTopThreads = `SELECT id, title, author, forum, message, votes, slug, created, state FROM "thread" ` +
+		`WHERE forum = $1 AND created >= $2 ORDER BY votes DESC, id LIMIT $3;`
+	qForumStatsStale = `SELECT EXISTS (SELECT 1 FROM "forum" f LEFT JOIN ` +
+		`(SELECT forum, sum(posts) AS posts, sum(threads) AS threads FROM "forum_activity" GROUP BY forum) a ON a.forum = f.slug ` +
+		`WHERE f.posts <> coalesce(a.posts, 0) OR f.threads <> coalesce(a.threads, 0));`
+
+	// REBUILD
+	qRebuildForumStats = `SELECT rebuild_forum_stats();`
+)
+
+type StatsRepository interface {
+	// GetForumStats counts the activity of a forum since since in buckets of
+	// the date_trunc unit bucket and ranks at most limit posters and threads.
+	// The zero since covers all time.
+	GetForumStats(ctx context.Context, slug string, since time.Time, bucket string, limit int64) (*core.ForumStats, error)
+	// RepairForumStats rebuilds the aggregates behind the stats when they
+	// disagree with the forum counters, e.g. after a crash emptied them, and
+	// reports whether it did.
+	RepairForumStats(ctx context.Context) (bool, error)
+}
+
+// statsRepositoryImpl reads the primary only: the aggregates are unlogged
+// tables, which replicas can't read, unlike the tables behind the listings.
+type statsRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *statsRepositoryImpl) GetForumStats(ctx context.Context, slug string, since time.Time, bucket string, limit int64) (*core.ForumStats, error) {
+	stats := &core.ForumStats{
+		Forum:      slug,
+		Bucket:     bucket,
+		Activity:   make([]*core.ForumActivity, 0),
+		TopPosters: make([]*core.ForumPoster, 0),
+		TopThreads: make([]*core.Thread, 0),
+	}
+
+	rows, err := repo.db.Query(ctx, qGetForumActivity, slug, since, bucket)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		a := &core.ForumActivity{}
+		if err := rows.Scan(&a.Start, &a.Posts, &a.Threads); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.Activity = append(stats.Activity, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = repo.db.Query(ctx, qGetForumPosters, slug, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		p := &core.ForumPoster{}
+		if err := rows.Scan(&p.Nickname, &p.Posts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.TopPosters = append(stats.TopPosters, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = repo.db.Query(ctx, qGetForumTopThreads, slug, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanThread(rows)
+		if err != nil {
+			return nil, err
+		}
+		stats.TopThreads = append(stats.TopThreads, t)
+	}
+	return stats, rows.Err()
+}
+
+func (repo *statsRepositoryImpl) RepairForumStats(ctx context.Context) (bool, error) {
+	var stale bool
+	if err := repo.db.QueryRow(ctx, qForumStatsStale).Scan(&stale); err != nil || !stale {
+		return false, err
+	}
+	_, err := repo.db.Exec(ctx, qRebuildForumStats)
+	return err == nil, err
+}
+
+func NewStatsRepository(db *pgxpool.Pool) *statsRepositoryImpl {
+	return &statsRepositoryImpl{db: db}
+}
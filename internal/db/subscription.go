@@ -0,0 +1,133 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// INSERT
+	qSubscribeThread = "INSERT INTO \"subscription\" (nickname, thread, since) VALUES ($1, $2, (SELECT COALESCE(max(id), 0) FROM \"post\")) " +
+		"ON CONFLICT (nickname, thread) WHERE thread IS NOT NULL DO UPDATE SET since = \"subscription\".since " +
+		"RETURNING nickname, COALESCE(thread, 0), COALESCE(forum, ''), since, created;"
+	qSubscribeForum = "INSERT INTO \"subscription\" (nickname, forum, since) VALUES ($1, $2, (SELECT COALESCE(max(id), 0) FROM \"post\")) " +
+		"ON CONFLICT (nickname, forum) WHERE forum IS NOT NULL DO UPDATE SET since = \"subscription\".since " +
+		"RETURNING nickname, COALESCE(thread, 0), COALESCE(forum, ''), since, created;"
+	qSetFeedSeen = "INSERT INTO \"feed_cursor\" (nickname, seen) VALUES ($1, $2) " +
+		"ON CONFLICT (nickname) DO UPDATE SET seen = GREATEST(\"feed_cursor\".seen, EXCLUDED.seen);"
+
+	// SELECT
+	qGetSubscriptions = "SELECT nickname, COALESCE(thread, 0), COALESCE(forum, ''), since, created FROM \"subscription\" " +
+		"WHERE nickname = $1 ORDER BY created, thread, forum;"
+	qGetFeed = "SELECT p.id, p.parent, p.author, p.message, p.isEdited, p.forum, p.thread, p.created, p.isDeleted, p.score FROM \"post\" p " +
+		"WHERE p.id > COALESCE((SELECT seen FROM \"feed_cursor\" WHERE nickname = $1), 0) AND NOT p.isDeleted AND p.author <> $1 " +
+		"AND EXISTS (SELECT 1 FROM \"subscription\" s WHERE s.nickname = $1 AND p.id > s.since AND (s.thread = p.thread OR s.forum = p.forum)) " +
+		"ORDER BY p.id LIMIT $2;"
+
+	// DELETE
+	qUnsubscribeThread = "DELETE FROM \"subscription\" WHERE nickname = $1 AND thread = $2;"
+	qUnsubscribeForum  = "DELETE FROM \"subscription\" WHERE nickname = $1 AND forum = $2;"
+)
+
+type SubscriptionRepository interface {
+	// SubscribeThread and SubscribeForum return the subscription of the user,
+	// the existing one when it is subscribed already.
+	SubscribeThread(ctx context.Context, nickname string, thread int64) (*core.Subscription, error)
+	SubscribeForum(ctx context.Context, nickname string, forum string) (*core.Subscription, error)
+	UnsubscribeThread(ctx context.Context, nickname string, thread int64) (bool, error)
+	UnsubscribeForum(ctx context.Context, nickname string, forum string) (bool, error)
+	GetSubscriptions(ctx context.Context, nickname string) ([]*core.Subscription, error)
+
+	// GetFeed returns, oldest first, the posts of others in the subscriptions
+	// of a user which are newer than the last one the user has seen.
+	GetFeed(ctx context.Context, nickname string, limit int64) ([]*core.Post, error)
+	// SetFeedSeen moves the last seen post of the feed forward to post.
+	SetFeedSeen(ctx context.Context, nickname string, post int64) error
+}
+
+type subscriptionRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *subscriptionRepositoryImpl) SubscribeThread(ctx context.Context, nickname string, thread int64) (*core.Subscription, error) {
+	return scanSubscription(repo.db.QueryRow(ctx, qSubscribeThread, nickname, thread))
+}
+
+func (repo *subscriptionRepositoryImpl) SubscribeForum(ctx context.Context, nickname string, forum string) (*core.Subscription, error) {
+	return scanSubscription(repo.db.QueryRow(ctx, qSubscribeForum, nickname, forum))
+}
+
+func (repo *subscriptionRepositoryImpl) UnsubscribeThread(ctx context.Context, nickname string, thread int64) (bool, error) {
+	res, err := repo.db.Exec(ctx, qUnsubscribeThread, nickname, thread)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func (repo *subscriptionRepositoryImpl) UnsubscribeForum(ctx context.Context, nickname string, forum string) (bool, error) {
+	res, err := repo.db.Exec(ctx, qUnsubscribeForum, nickname, forum)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func (repo *subscriptionRepositoryImpl) GetSubscriptions(ctx context.Context, nickname string) ([]*core.Subscription, error) {
+	rows, err := repo.db.Query(ctx, qGetSubscriptions, nickname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]*core.Subscription, 0)
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (repo *subscriptionRepositoryImpl) GetFeed(ctx context.Context, nickname string, limit int64) ([]*core.Post, error) {
+	rows, err := repo.db.Query(ctx, qGetFeed, nickname, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]*core.Post, 0)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (repo *subscriptionRepositoryImpl) SetFeedSeen(ctx context.Context, nickname string, post int64) error {
+	_, err := repo.db.Exec(ctx, qSetFeedSeen, nickname, post)
+	return err
+}
+
+func scanSubscription(row pgx.Row) (*core.Subscription, error) {
+	sub := &core.Subscription{}
+	if err := row.Scan(&sub.Nickname, &sub.Thread, &sub.Forum, &sub.Since, &sub.Created); err != nil {
+		return nil, wrapErr(err)
+	}
+	sub.Kind = core.SubscriptionThread
+	if sub.Forum != "" {
+		sub.Kind = core.SubscriptionForum
+	}
+	return sub, nil
+}
+
+func NewSubscriptionRepository(db *pgxpool.Pool) *subscriptionRepositoryImpl {
+	return &subscriptionRepositoryImpl{db: db}
+}
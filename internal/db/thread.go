@@ -3,26 +3,33 @@ package db
 import (
 	"SYBD/internal/model/core"
 	"context"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
 )
 
 const (
 	// INSERT
-	qCreateThread = "INSERT INTO \"thread\" (title, author, forum, message, slug, created) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, title, author, forum, message, votes, slug, created;"
+	qCreateThread = "INSERT INTO \"thread\" (title, author, forum, message, slug, created) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, title, author, forum, message, votes, slug, created, state;"
 
 	//UPDATE
-	qUpdateThread = "UPDATE \"thread\" SET title = $2, message = $3 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created;"
+	qUpdateThread      = "UPDATE \"thread\" SET title = $2, message = $3 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created, state;"
+	qUpdateThreadState = "UPDATE \"thread\" SET state = $2 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created, state;"
 
 	// SELECT
-	qGetThreadBySlug = "SELECT id, title, author, forum, message, votes, slug, created FROM \"thread\" WHERE slug = $1;"
-	qGetThreadByID   = "SELECT id, title, author, forum, message, votes, slug, created FROM \"thread\" WHERE id = $1;"
+	qGetThreadBySlug = "SELECT id, title, author, forum, message, votes, slug, created, state FROM \"thread\" WHERE slug = $1;"
+	qGetThreadByID   = "SELECT id, title, author, forum, message, votes, slug, created, state FROM \"thread\" WHERE id = $1;"
 )
 
 type ThreadRepository interface {
 	CreateThread(ctx context.Context, thread *core.Thread) (*core.Thread, error)
 	UpdateThread(ctx context.Context, id int64, title string, message string) (*core.Thread, error)
+	UpdateThreadState(ctx context.Context, id int64, state string) (*core.Thread, error)
 	GetThread(ctx context.Context, slug string) (*core.Thread, error)
 	GetThreadByID(ctx context.Context, id int64) (*core.Thread, error)
+	// ResolveThread looks a thread up by id when slugOrID is a number and by
+	// slug otherwise.
+	ResolveThread(ctx context.Context, slugOrID string) (*core.Thread, error)
 }
 
 type threadRepositoryImpl struct {
@@ -30,74 +37,86 @@ type threadRepositoryImpl struct {
 }
 
 func (repo *threadRepositoryImpl) CreateThread(ctx context.Context, thread *core.Thread) (*core.Thread, error) {
-	t := &core.Thread{}
-	err := repo.dbConn.QueryRow(ctx,
+	t, err := scanThread(repo.dbConn.QueryRow(ctx,
 		qCreateThread,
 		thread.Title,
 		thread.Author,
 		thread.Forum,
 		thread.Message,
 		thread.Slug,
-		thread.Created).
-		Scan(&t.ID,
-			&t.Title,
-			&t.Author,
-			&t.Forum,
-			&t.Message,
-			&t.Votes,
-			&t.Slug,
-			&t.Created)
-	return t, err
+		thread.Created))
+	if err != nil {
+		return &core.Thread{}, err
+	}
+	return t, nil
 }
 
 func (repo *threadRepositoryImpl) GetThread(ctx context.Context, slug string) (*core.Thread, error) {
-	t := &core.Thread{}
-	err := repo.dbConn.QueryRow(ctx,
+	t, err := scanThread(repo.dbConn.QueryRow(ctx,
 		qGetThreadBySlug,
-		slug).
-		Scan(&t.ID,
-			&t.Title,
-			&t.Author,
-			&t.Forum,
-			&t.Message,
-			&t.Votes,
-			&t.Slug,
-			&t.Created)
-	return t, wrapErr(err)
+		slug))
+	if err != nil {
+		return &core.Thread{}, wrapErr(err)
+	}
+	return t, nil
 }
 
 func (repo *threadRepositoryImpl) GetThreadByID(ctx context.Context, id int64) (*core.Thread, error) {
-	t := &core.Thread{}
-	err := repo.dbConn.QueryRow(ctx,
+	t, err := scanThread(repo.dbConn.QueryRow(ctx,
 		qGetThreadByID,
-		id).
-		Scan(&t.ID,
-			&t.Title,
-			&t.Author,
-			&t.Forum,
-			&t.Message,
-			&t.Votes,
-			&t.Slug,
-			&t.Created)
-	return t, wrapErr(err)
+		id))
+	if err != nil {
+		return &core.Thread{}, wrapErr(err)
+	}
+	return t, nil
+}
+
+func (repo *threadRepositoryImpl) ResolveThread(ctx context.Context, slugOrID string) (*core.Thread, error) {
+	if id, err := strconv.ParseInt(slugOrID, 10, 64); err == nil {
+		return repo.GetThreadByID(ctx, id)
+	}
+	return repo.GetThread(ctx, slugOrID)
 }
 
 func (repo *threadRepositoryImpl) UpdateThread(ctx context.Context, id int64, title string, message string) (*core.Thread, error) {
-	t := &core.Thread{}
-	err := repo.dbConn.QueryRow(ctx,
+	t, err := scanThread(repo.dbConn.QueryRow(ctx,
 		qUpdateThread,
 		id,
 		title,
-		message).
-		Scan(&t.ID,
-			&t.Title,
-			&t.Author,
-			&t.Forum,
-			&t.Message,
-			&t.Votes,
-			&t.Slug,
-			&t.Created)
-	return t, wrapErr(err)
+		message))
+	if err != nil {
+		return &core.Thread{}, wrapErr(err)
+	}
+	return t, nil
+}
+
+func (repo *threadRepositoryImpl) UpdateThreadState(ctx context.Context, id int64, state string) (*core.Thread, error) {
+	t, err := scanThread(repo.dbConn.QueryRow(ctx,
+		qUpdateThreadState,
+		id,
+		state))
+	if err != nil {
+		return &core.Thread{}, wrapErr(err)
+	}
+	return t, nil
+}
+
+// scanThread reads a row selected with the thread columns.
+func scanThread(row pgx.Row) (*core.Thread, error) {
+	t := &core.Thread{}
+	err := row.Scan(&t.ID,
+		&t.Title,
+		&t.Author,
+		&t.Forum,
+		&t.Message,
+		&t.Votes,
+		&t.Slug,
+		&t.Created,
+		&t.State)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
 func NewThreadRepository(dbConn *pgxpool.Pool) (*threadRepositoryImpl, error) {
@@ -1,41 +1,61 @@
 package db
 
 import (
+	"SYBD/internal/constants"
 	"SYBD/internal/model/core"
 	"context"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 const (
 	// INSERT Query
-	qCreateUser = "INSERT INTO \"user\" (nickname, fullname, about, email) VALUES ($1, $2, $3, $4);"
+	qCreateUser = "INSERT INTO \"user\" (nickname, fullname, about, email, password_hash) VALUES ($1, $2, $3, $4, $5);"
 
 	// SELECT Query
 	qGetUserByNickname = "SELECT nickname, fullname, about, email FROM \"user\" WHERE nickname = $1;"
+	qGetUsers          = "SELECT nickname, fullname, about, email FROM \"user\" WHERE nickname = ANY($1::text[]::citext[]);"
 	qGetUserByEmail    = "SELECT nickname, fullname, about, email FROM \"user\" WHERE email = $1;"
 	qGetSimilaryUsers  = "SELECT nickname, fullname, about, email FROM \"user\" WHERE email = $1 OR nickname = $2;"
+	qGetPasswordHash   = "SELECT nickname, password_hash FROM \"user\" WHERE nickname = $1;"
+	qSetPasswordHash   = "UPDATE \"user\" SET password_hash = $2 WHERE nickname = $1;"
+	qGetUserRole       = "SELECT role FROM \"user\" WHERE nickname = $1;"
+	qSetUserRole       = "UPDATE \"user\" SET role = $2 WHERE nickname = $1;"
+	qGetUserID         = "SELECT id FROM \"user\" WHERE nickname = $1;"
+	qGetUserIDLocked   = "SELECT id, nickname FROM \"user\" WHERE nickname = $1 FOR UPDATE;"
 	qUpdateUser        = "UPDATE \"user\" SET fullname = COALESCE(NULLIF(TRIM($1), ''), fullname), about = COALESCE(NULLIF(TRIM($2), ''), about), email = COALESCE(NULLIF(TRIM($3), ''), email) WHERE nickname = $4 RETURNING fullname, about, email;"
 )
 
 type UserRepository interface {
-	CreateUser(ctx context.Context, user *core.User) error
+	CreateUser(ctx context.Context, user *core.User, passwordHash string) error
 	GetUserByNickname(ctx context.Context, nickname string) (*core.User, error)
+	// GetUsers returns the users with the given nicknames which exist.
+	GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*core.User, error)
 	GetSimilaryUsers(ctx context.Context, email string, nickname string) ([]core.User, error)
 	UpdateUser(ctx context.Context, user *core.User) (*core.User, error)
+	// GetUserID returns the id of the user. Ids are never reused, so a nickname
+	// registered again after a deletion gets a new one.
+	GetUserID(ctx context.Context, nickname string) (int64, error)
+	GetPasswordHash(ctx context.Context, nickname string) (string, string, error)
+	SetPasswordHash(ctx context.Context, nickname string, passwordHash string) error
+	GetUserRole(ctx context.Context, nickname string) (string, error)
+	SetUserRole(ctx context.Context, nickname string, role string) error
+	DeleteUser(ctx context.Context, nickname string, mode string, dryRun bool) (*core.UserDeletion, error)
 }
 
 type userRepositoryImpl struct {
 	db *pgxpool.Pool
 }
 
-func (repo *userRepositoryImpl) CreateUser(ctx context.Context, user *core.User) error {
+func (repo *userRepositoryImpl) CreateUser(ctx context.Context, user *core.User, passwordHash string) error {
 	_, err := repo.db.Exec(ctx,
 		qCreateUser,
 		user.Nickname,
 		user.FullName,
 		user.About,
-		user.Email)
+		user.Email,
+		passwordHash)
 	return wrapErr(err)
 }
 
@@ -51,6 +71,24 @@ func (repo *userRepositoryImpl) GetUserByNickname(ctx context.Context, nickname
 	return user, wrapErr(err)
 }
 
+func (repo *userRepositoryImpl) GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	rows, err := repo.db.Query(ctx, qGetUsers, nicknames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*core.User, 0, len(nicknames))
+	for rows.Next() {
+		u := &core.User{}
+		if err := rows.Scan(&u.Nickname, &u.FullName, &u.About, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
 func (repo *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*core.User, error) {
 	user := &core.User{}
 	err := repo.db.QueryRow(ctx,
@@ -96,11 +134,240 @@ func (repo *userRepositoryImpl) UpdateUser(ctx context.Context, user *core.User)
 		Scan(&updatedUser.FullName,
 			&updatedUser.About,
 			&updatedUser.Email); err != nil {
-		return nil, err
+		return nil, wrapErr(err)
 	}
 	return updatedUser, nil
 }
 
+func (repo *userRepositoryImpl) GetUserID(ctx context.Context, nickname string) (int64, error) {
+	var id int64
+	err := repo.db.QueryRow(ctx, qGetUserID, nickname).Scan(&id)
+	return id, wrapErr(err)
+}
+
+// GetPasswordHash returns the stored nickname of the user and its password hash.
+func (repo *userRepositoryImpl) GetPasswordHash(ctx context.Context, nickname string) (string, string, error) {
+	var hash string
+	err := repo.db.QueryRow(ctx, qGetPasswordHash, nickname).Scan(&nickname, &hash)
+	return nickname, hash, wrapErr(err)
+}
+
+func (repo *userRepositoryImpl) SetPasswordHash(ctx context.Context, nickname string, passwordHash string) error {
+	res, err := repo.db.Exec(ctx, qSetPasswordHash, nickname, passwordHash)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return constants.ErrDBNotFound
+	}
+	return nil
+}
+
+func (repo *userRepositoryImpl) GetUserRole(ctx context.Context, nickname string) (string, error) {
+	var role string
+	err := repo.db.QueryRow(ctx, qGetUserRole, nickname).Scan(&role)
+	return role, wrapErr(err)
+}
+
+func (repo *userRepositoryImpl) SetUserRole(ctx context.Context, nickname string, role string) error {
+	res, err := repo.db.Exec(ctx, qSetUserRole, nickname, role)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return constants.ErrDBNotFound
+	}
+	return nil
+}
+
+const (
+	qCreatePlaceholderUser = "INSERT INTO \"user\" (nickname, fullname, about, email) VALUES ($1, $2, '', $1 || '@deleted.invalid');"
+	qDeleteUser            = "DELETE FROM \"user\" WHERE nickname = $1;"
+	qTransferForums        = "UPDATE \"forum\" SET \"user\" = $2 WHERE \"user\" = $1;"
+	qReassignRevisions     = "UPDATE \"post_revision\" SET editor = $2 WHERE editor = $1;"
+
+	// anonymize
+	qReassignThreads     = "UPDATE \"thread\" SET author = $2 WHERE lower(author) = lower($1);"
+	qReassignPosts       = "UPDATE \"post\" SET author = $2 WHERE lower(author) = lower($1);"
+	qReassignVotes       = "UPDATE \"vote\" SET nickname = $2 WHERE lower(nickname) = lower($1);"
+	qReassignPostVotes   = "UPDATE \"post_vote\" SET nickname = $2 WHERE nickname = $1;"
+	qReassignForumUsers  = "INSERT INTO \"forum_user\" (forum, nickname, fullname, about, email) SELECT fu.forum, u.nickname, u.fullname, u.about, u.email FROM \"forum_user\" fu, \"user\" u WHERE fu.nickname = $1 AND u.nickname = $2 ON CONFLICT DO NOTHING;"
+	qDeleteUserForumUser = "DELETE FROM \"forum_user\" WHERE nickname = $1;"
+
+	// purge
+	qGetUserThreads     = "SELECT id, forum FROM \"thread\" WHERE lower(author) = lower($1);"
+	qDeleteUserVotes    = "DELETE FROM \"vote\" WHERE lower(nickname) = lower($1);"
+	qPurgePostVotes     = "DELETE FROM \"post_vote\" WHERE nickname = $1;"
+	qDeleteThreadsVotes = "DELETE FROM \"vote\" WHERE thread = ANY($1);"
+	qDeleteThreadsPosts = "DELETE FROM \"post\" WHERE thread = ANY($1);"
+	qDeleteThreads      = "DELETE FROM \"thread\" WHERE id = ANY($1);"
+	qDecrementThreads   = "UPDATE \"forum\" SET threads = threads - 1 WHERE slug = $1;"
+	qTombstoneUserPosts = "UPDATE \"post\" p SET isDeleted = true, message = '', author = $2 WHERE lower(p.author) = lower($1) AND EXISTS (SELECT 1 FROM \"post\" c WHERE c.parent = p.id);"
+	qDeleteUserPosts    = "DELETE FROM \"post\" WHERE lower(author) = lower($1);"
+	qPruneForumUsers    = "DELETE FROM \"forum_user\" fu WHERE fu.forum = ANY($1) AND NOT EXISTS (SELECT 1 FROM \"thread\" t WHERE t.forum = fu.forum AND lower(t.author) = lower(fu.nickname)) AND NOT EXISTS (SELECT 1 FROM \"post\" p WHERE p.forum = fu.forum AND lower(p.author) = lower(fu.nickname));"
+)
+
+// DeleteUser removes the user and either re-attributes or purges its content
+// in one transaction. A dry run performs the same statements and rolls back,
+// so the report matches what a real run would do.
+func (repo *userRepositoryImpl) DeleteUser(ctx context.Context, nickname string, mode string, dryRun bool) (*core.UserDeletion, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	report := &core.UserDeletion{Mode: mode, DryRun: dryRun}
+	if err := tx.QueryRow(ctx, qGetUserIDLocked, nickname).Scan(&id, &report.Nickname); err != nil {
+		return nil, wrapErr(err)
+	}
+	report.Placeholder = core.DeletedUserNickname(id)
+
+	// Anonymizing copies the forum memberships of the placeholder row, so it
+	// is created first.
+	if mode != core.UserDeletePurge {
+		if _, err := tx.Exec(ctx, qCreatePlaceholderUser, report.Placeholder, core.DeletedUserFullName); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode == core.UserDeletePurge {
+		err = purgeUserContent(ctx, tx, report)
+	} else {
+		err = reassignUserContent(ctx, tx, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := tx.Exec(ctx, qReassignRevisions, report.Nickname, report.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(ctx, qTransferForums, report.Nickname, report.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	report.Forums = res.RowsAffected()
+
+	if mode == core.UserDeletePurge {
+		if report.Tombstoned+report.Forums+revisions.RowsAffected() == 0 {
+			report.Placeholder = ""
+		} else if _, err := tx.Exec(ctx, qCreatePlaceholderUser, report.Placeholder, core.DeletedUserFullName); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, qDeleteUserForumUser, report.Nickname); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, qDeleteUser, report.Nickname); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	return report, tx.Commit(ctx)
+}
+
+func reassignUserContent(ctx context.Context, tx pgx.Tx, report *core.UserDeletion) error {
+	counters := []struct {
+		query string
+		count *int64
+	}{
+		{qReassignThreads, &report.Threads},
+		{qReassignPosts, &report.Posts},
+		{qReassignVotes, &report.Votes},
+		{qReassignPostVotes, &report.Votes},
+		{qReassignForumUsers, nil},
+	}
+	for _, c := range counters {
+		res, err := tx.Exec(ctx, c.query, report.Nickname, report.Placeholder)
+		if err != nil {
+			return err
+		}
+		if c.count != nil {
+			*c.count += res.RowsAffected()
+		}
+	}
+	return nil
+}
+
+// purgeUserContent deletes the user's votes, its threads with everything posted
+// in them and its posts. Posts with replies become tombstones so the replies
+// keep their place in the tree. Vote and post triggers keep thread.votes and
+// forum.posts right; forum.threads and forum_user are fixed up here.
+func purgeUserContent(ctx context.Context, tx pgx.Tx, report *core.UserDeletion) error {
+	res, err := tx.Exec(ctx, qDeleteUserVotes, report.Nickname)
+	if err != nil {
+		return err
+	}
+	report.Votes = res.RowsAffected()
+	if res, err = tx.Exec(ctx, qPurgePostVotes, report.Nickname); err != nil {
+		return err
+	}
+	report.Votes += res.RowsAffected()
+
+	rows, err := tx.Query(ctx, qGetUserThreads, report.Nickname)
+	if err != nil {
+		return err
+	}
+	var threads []int64
+	var forums []string
+	for rows.Next() {
+		var id int64
+		var forum string
+		if err := rows.Scan(&id, &forum); err != nil {
+			rows.Close()
+			return err
+		}
+		threads = append(threads, id)
+		forums = append(forums, forum)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(threads) > 0 {
+		if _, err := tx.Exec(ctx, qDeleteThreadsVotes, threads); err != nil {
+			return err
+		}
+		if res, err = tx.Exec(ctx, qDeleteThreadsPosts, threads); err != nil {
+			return err
+		}
+		report.Posts += res.RowsAffected()
+		if res, err = tx.Exec(ctx, qDeleteThreads, threads); err != nil {
+			return err
+		}
+		report.Threads = res.RowsAffected()
+		for _, forum := range forums {
+			if _, err := tx.Exec(ctx, qDecrementThreads, forum); err != nil {
+				return err
+			}
+		}
+	}
+
+	if res, err = tx.Exec(ctx, qTombstoneUserPosts, report.Nickname, report.Placeholder); err != nil {
+		return err
+	}
+	report.Tombstoned = res.RowsAffected()
+	if res, err = tx.Exec(ctx, qDeleteUserPosts, report.Nickname); err != nil {
+		return err
+	}
+	report.Posts += res.RowsAffected()
+
+	if len(forums) > 0 {
+		if _, err := tx.Exec(ctx, qPruneForumUsers, forums); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func NewUserRepository(db *pgxpool.Pool) (*userRepositoryImpl, error) {
 	return &userRepositoryImpl{db: db}, nil
 }
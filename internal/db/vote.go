@@ -4,23 +4,38 @@ import (
 	"SYBD/internal/model/core"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 const (
 	// INSERT
-	qCreateVote = "INSERT INTO \"vote\" (nickname, thread, voice) VALUES ($1, $2, $3);"
+	qCreateVote     = "INSERT INTO \"vote\" (nickname, thread, voice) VALUES ($1, $2, $3);"
+	qCreatePostVote = "INSERT INTO \"post_vote\" (nickname, post, voice) VALUES ($1, $2, $3);"
 
 	// SELECT
-	qExists = "SELECT voice from \"vote\" WHERE nickname = $1 AND thread = $2;"
-	qUpdate = "UPDATE \"vote\" SET voice = $3 WHERE thread = $1 AND nickname = $2 AND voice != $3;"
+	qGetVote = "SELECT voice from \"vote\" WHERE nickname = $1 AND thread = $2;"
+	qUpdate  = "UPDATE \"vote\" SET voice = $3 WHERE thread = $1 AND nickname = $2 AND voice != $3;"
+	qDelete  = "DELETE FROM \"vote\" WHERE thread = $1 AND nickname = $2 RETURNING voice;"
+
+	qPostVoteExists = "SELECT voice FROM \"post_vote\" WHERE nickname = $1 AND post = $2;"
+	qUpdatePostVote = "UPDATE \"post_vote\" SET voice = $3 WHERE post = $1 AND nickname = $2 AND voice != $3;"
 )
 
 type VoteRepository interface {
 	CreateVote(ctx context.Context, vote *core.Vote) error
-	VoteExists(ctx context.Context, nickname string, threadID int64) (bool, error)
+	// GetVote returns the voice of the user on the thread.
+	GetVote(ctx context.Context, nickname string, threadID int64) (int64, error)
 	UpdateVote(ctx context.Context, threadID int64, nickname string, voice int64) (bool, error)
+	// DeleteVote removes the vote of the user and returns the voice it had.
+	DeleteVote(ctx context.Context, threadID int64, nickname string) (int64, error)
+	// GetVotes lists the votes of a thread ordered by nickname.
+	GetVotes(ctx context.Context, threadID int64, limit int64, after *core.Cursor) ([]*core.Vote, error)
+
+	CreatePostVote(ctx context.Context, vote *core.PostVote) error
+	PostVoteExists(ctx context.Context, nickname string, postID int64) (bool, error)
+	UpdatePostVote(ctx context.Context, postID int64, nickname string, voice int64) (bool, error)
 }
 
 type voteRepositoryImpl struct {
@@ -36,13 +51,70 @@ func (repo *voteRepositoryImpl) CreateVote(ctx context.Context, vote *core.Vote)
 	return wrapErr(err)
 }
 
-func (repo *voteRepositoryImpl) VoteExists(ctx context.Context, nickname string, threadID int64) (bool, error) {
-	voice := 0
+func (repo *voteRepositoryImpl) GetVote(ctx context.Context, nickname string, threadID int64) (int64, error) {
+	var voice int64
 	err := repo.db.QueryRow(ctx,
-		qExists,
+		qGetVote,
 		nickname,
 		threadID).Scan(&voice)
+	return voice, wrapErr(err)
+}
+
+func (repo *voteRepositoryImpl) UpdateVote(ctx context.Context, threadID int64, nickname string, voice int64) (bool, error) {
+	res, err := repo.db.Exec(ctx,
+		qUpdate,
+		threadID,
+		nickname,
+		voice)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+func (repo *voteRepositoryImpl) DeleteVote(ctx context.Context, threadID int64, nickname string) (int64, error) {
+	var voice int64
+	err := repo.db.QueryRow(ctx, qDelete, threadID, nickname).Scan(&voice)
+	return voice, wrapErr(err)
+}
+
+func (repo *voteRepositoryImpl) GetVotes(ctx context.Context, threadID int64, limit int64, after *core.Cursor) ([]*core.Vote, error) {
+	query := "SELECT nickname, thread, voice FROM \"vote\" WHERE thread = $1 "
+	args := []interface{}{threadID}
+	if after != nil {
+		query += "AND nickname > $2 "
+		args = append(args, after.Nickname)
+	}
+	query += "ORDER BY nickname "
+	if limit > 0 {
+		query += fmt.Sprintf("LIMIT %d ", limit)
+	}
+
+	rows, err := repo.db.Query(ctx, query, args...)
 	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := make([]*core.Vote, 0)
+	for rows.Next() {
+		v := &core.Vote{}
+		if err := rows.Scan(&v.Nickname, &v.ThreadID, &v.Voice); err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+func (repo *voteRepositoryImpl) CreatePostVote(ctx context.Context, vote *core.PostVote) error {
+	_, err := repo.db.Exec(ctx, qCreatePostVote, vote.Nickname, vote.PostID, vote.Voice)
+	return wrapErr(err)
+}
+
+func (repo *voteRepositoryImpl) PostVoteExists(ctx context.Context, nickname string, postID int64) (bool, error) {
+	voice := 0
+	if err := repo.db.QueryRow(ctx, qPostVoteExists, nickname, postID).Scan(&voice); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return false, nil
 		}
@@ -51,12 +123,8 @@ func (repo *voteRepositoryImpl) VoteExists(ctx context.Context, nickname string,
 	return true, nil
 }
 
-func (repo *voteRepositoryImpl) UpdateVote(ctx context.Context, threadID int64, nickname string, voice int64) (bool, error) {
-	res, err := repo.db.Exec(ctx,
-		qUpdate,
-		threadID,
-		nickname,
-		voice)
+func (repo *voteRepositoryImpl) UpdatePostVote(ctx context.Context, postID int64, nickname string, voice int64) (bool, error) {
+	res, err := repo.db.Exec(ctx, qUpdatePostVote, postID, nickname, voice)
 	if err != nil {
 		return false, err
 	}
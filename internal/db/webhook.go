@@ -0,0 +1,264 @@
+package db
+
+import (
+	"SYBD/internal/model/core"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+const (
+	// INSERT
+	qCreateWebhook  = "INSERT INTO \"webhook\" (forum, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id, forum, url, secret, events, created;"
+	qQueueDelivery  = "INSERT INTO \"webhook_delivery\" (webhook, event, type, payload, next_attempt) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (webhook, event) DO NOTHING;"
+	qSetCursor      = "INSERT INTO \"webhook_cursor\" (id, txid, event) VALUES (1, $1, $2) ON CONFLICT (id) DO UPDATE SET txid = EXCLUDED.txid, event = EXCLUDED.event WHERE (\"webhook_cursor\".txid, \"webhook_cursor\".event) < (EXCLUDED.txid, EXCLUDED.event);"
+	qRecordAttempt  = "INSERT INTO \"webhook_attempt\" (delivery, attempt, code, error, duration, created) SELECT $1::bigint, coalesce(max(attempt), 0) + 1, $2::int, $3::text, $4::int, $5::timestamptz FROM \"webhook_attempt\" WHERE delivery = $1::bigint RETURNING attempt;"
+	qUpdateDelivery = "UPDATE \"webhook_delivery\" SET status = $2, attempts = attempts + 1, next_attempt = $3 WHERE id = $1;"
+
+	// SELECT
+	qGetWebhook   = "SELECT id, forum, url, secret, events, created FROM \"webhook\" WHERE id = $1;"
+	qGetWebhooks  = "SELECT id, forum, url, secret, events, created FROM \"webhook\" WHERE $1 = '' OR forum = $1::citext ORDER BY id;"
+	qGetCursor    = "SELECT txid, event FROM \"webhook_cursor\" WHERE id = 1;"
+	qGetDelivery  = "SELECT id, webhook, event, type, payload, status, attempts, next_attempt, created FROM \"webhook_delivery\" WHERE id = $1;"
+	qGetDelivered = "SELECT id, webhook, event, type, payload, status, attempts, next_attempt, created FROM \"webhook_delivery\" WHERE webhook = $1 AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3;"
+	qGetAttempts  = "SELECT delivery, attempt, code, error, duration, created FROM \"webhook_attempt\" WHERE delivery = ANY($1) ORDER BY delivery, attempt;"
+
+	// UPDATE
+	qClaimDeliveries = "UPDATE \"webhook_delivery\" d SET next_attempt = $2 FROM \"webhook\" w " +
+		"WHERE w.id = d.webhook AND d.id IN (SELECT id FROM \"webhook_delivery\" WHERE status = 'pending' AND next_attempt <= $1 ORDER BY next_attempt LIMIT $3 FOR UPDATE SKIP LOCKED) " +
+		"RETURNING d.id, d.webhook, d.event, d.type, d.payload, d.status, d.attempts, d.next_attempt, d.created, w.url, w.secret;"
+	qRedeliver = "UPDATE \"webhook_delivery\" SET status = 'pending', attempts = 0, next_attempt = $2 WHERE id = $1 RETURNING id, webhook, event, type, payload, status, attempts, next_attempt, created;"
+
+	// DELETE
+	qDeleteWebhook = "DELETE FROM \"webhook\" WHERE id = $1;"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, hook *core.Webhook) (*core.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*core.Webhook, error)
+	// GetWebhooks returns the webhooks of forum, or of all forums when it is
+	// empty.
+	GetWebhooks(ctx context.Context, forum string) ([]*core.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) (bool, error)
+	// GetWebhookCursor returns the position of the last event deliveries were
+	// queued for; ok is false until the cursor is first set.
+	GetWebhookCursor(ctx context.Context) (cursor core.EventPosition, ok bool, err error)
+	// QueueDeliveries adds deliveries, skipping those already queued, and
+	// moves the cursor forward to cursor.
+	QueueDeliveries(ctx context.Context, deliveries []*core.WebhookDelivery, cursor core.EventPosition) error
+	// ClaimDeliveries returns up to limit pending deliveries due at now and
+	// postpones them to now+lease, so other workers skip them meanwhile.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]*core.WebhookDelivery, error)
+	// RecordAttempt logs an attempt and sets the status and next attempt of
+	// its delivery.
+	RecordAttempt(ctx context.Context, attempt *core.WebhookAttempt, status string, nextAttempt *time.Time) error
+	GetDelivery(ctx context.Context, id int64) (*core.WebhookDelivery, error)
+	// GetDeliveries returns the deliveries of a webhook newest first, starting
+	// below the id before when it is set.
+	GetDeliveries(ctx context.Context, webhook int64, before int64, limit int64) ([]*core.WebhookDelivery, error)
+	// Redeliver queues a delivery again for now with a fresh attempt budget.
+	Redeliver(ctx context.Context, id int64, now time.Time) (*core.WebhookDelivery, error)
+}
+
+type webhookRepositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func (repo *webhookRepositoryImpl) CreateWebhook(ctx context.Context, hook *core.Webhook) (*core.Webhook, error) {
+	created, err := scanWebhook(repo.db.QueryRow(ctx, qCreateWebhook, hook.Forum, hook.URL, hook.Secret, hook.Events))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return created, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhook(ctx context.Context, id int64) (*core.Webhook, error) {
+	hook, err := scanWebhook(repo.db.QueryRow(ctx, qGetWebhook, id))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return hook, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhooks(ctx context.Context, forum string) ([]*core.Webhook, error) {
+	rows, err := repo.db.Query(ctx, qGetWebhooks, forum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]*core.Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+func (repo *webhookRepositoryImpl) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	res, err := repo.db.Exec(ctx, qDeleteWebhook, id)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+func (repo *webhookRepositoryImpl) GetWebhookCursor(ctx context.Context) (core.EventPosition, bool, error) {
+	var cursor core.EventPosition
+	if err := repo.db.QueryRow(ctx, qGetCursor).Scan(&cursor.TxID, &cursor.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return cursor, false, nil
+		}
+		return cursor, false, err
+	}
+	return cursor, true, nil
+}
+
+func (repo *webhookRepositoryImpl) QueueDeliveries(ctx context.Context, deliveries []*core.WebhookDelivery, cursor core.EventPosition) error {
+	return repo.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, d := range deliveries {
+			batch.Queue(qQueueDelivery, d.Webhook, d.Event, d.Type, d.Payload, d.NextAttempt)
+		}
+		batch.Queue(qSetCursor, cursor.TxID, cursor.ID)
+
+		res := tx.SendBatch(ctx, batch)
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := res.Exec(); err != nil {
+				_ = res.Close()
+				return err
+			}
+		}
+		return res.Close()
+	})
+}
+
+func (repo *webhookRepositoryImpl) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]*core.WebhookDelivery, error) {
+	rows, err := repo.db.Query(ctx, qClaimDeliveries, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*core.WebhookDelivery, 0)
+	for rows.Next() {
+		d := &core.WebhookDelivery{}
+		if err := rows.Scan(&d.ID, &d.Webhook, &d.Event, &d.Type, &d.Payload, &d.Status,
+			&d.Attempts, &d.NextAttempt, &d.Created, &d.URL, &d.Secret); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (repo *webhookRepositoryImpl) RecordAttempt(ctx context.Context, attempt *core.WebhookAttempt, status string, nextAttempt *time.Time) error {
+	return repo.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, qRecordAttempt, attempt.Delivery, attempt.Code, attempt.Error,
+			attempt.Duration, attempt.Created).Scan(&attempt.Attempt); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, qUpdateDelivery, attempt.Delivery, status, nextAttempt)
+		return err
+	})
+}
+
+func (repo *webhookRepositoryImpl) GetDelivery(ctx context.Context, id int64) (*core.WebhookDelivery, error) {
+	d, err := scanDelivery(repo.db.QueryRow(ctx, qGetDelivery, id))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	if err := repo.withHistory(ctx, []*core.WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (repo *webhookRepositoryImpl) GetDeliveries(ctx context.Context, webhook int64, before int64, limit int64) ([]*core.WebhookDelivery, error) {
+	rows, err := repo.db.Query(ctx, qGetDelivered, webhook, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*core.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := repo.withHistory(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (repo *webhookRepositoryImpl) Redeliver(ctx context.Context, id int64, now time.Time) (*core.WebhookDelivery, error) {
+	d, err := scanDelivery(repo.db.QueryRow(ctx, qRedeliver, id, now))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	if err := repo.withHistory(ctx, []*core.WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// withHistory loads the attempts of deliveries.
+func (repo *webhookRepositoryImpl) withHistory(ctx context.Context, deliveries []*core.WebhookDelivery) error {
+	byID := make(map[int64]*core.WebhookDelivery, len(deliveries))
+	ids := make([]int64, 0, len(deliveries))
+	for _, d := range deliveries {
+		d.History = make([]*core.WebhookAttempt, 0)
+		byID[d.ID] = d
+		ids = append(ids, d.ID)
+	}
+
+	rows, err := repo.db.Query(ctx, qGetAttempts, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &core.WebhookAttempt{}
+		if err := rows.Scan(&a.Delivery, &a.Attempt, &a.Code, &a.Error, &a.Duration, &a.Created); err != nil {
+			return err
+		}
+		byID[a.Delivery].History = append(byID[a.Delivery].History, a)
+	}
+	return rows.Err()
+}
+
+func scanWebhook(row pgx.Row) (*core.Webhook, error) {
+	hook := &core.Webhook{}
+	if err := row.Scan(&hook.ID, &hook.Forum, &hook.URL, &hook.Secret, &hook.Events, &hook.Created); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func scanDelivery(row pgx.Row) (*core.WebhookDelivery, error) {
+	d := &core.WebhookDelivery{}
+	if err := row.Scan(&d.ID, &d.Webhook, &d.Event, &d.Type, &d.Payload, &d.Status,
+		&d.Attempts, &d.NextAttempt, &d.Created); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *webhookRepositoryImpl {
+	return &webhookRepositoryImpl{db: db}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"SYBD/internal/constants"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor kinds, one per sort order that supports keyset pagination.
+const (
+	CursorThreads         = "threads"
+	CursorThreadsTop      = "threads_top"
+	CursorThreadsActive   = "threads_active"
+	CursorThreadsHot      = "threads_hot"
+	CursorUsers           = "users"
+	CursorPostsFlat       = "flat"
+	CursorPostsTree       = "tree"
+	CursorPostsParentTree = "parent_tree"
+	CursorPostsTop        = "top"
+	CursorSearch          = "search"
+	CursorDeliveries      = "deliveries"
+	CursorNotifications   = "notifications"
+	CursorVotes           = "votes"
+)
+
+// Cursor is the keyset position of the last row of a page. Only the fields
+// of the sort key it was issued for are set.
+type Cursor struct {
+	Sort     string    `json:"s"`
+	Created  time.Time `json:"c"`
+	ID       int64     `json:"i,omitempty"`
+	Nickname string    `json:"n,omitempty"`
+	Score    float64   `json:"r,omitempty"`
+	Kind     string    `json:"k,omitempty"`
+}
+
+// Encode returns the opaque representation handed out to clients.
+func (c *Cursor) Encode() string {
+	buf, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodeCursor parses a cursor issued by Encode and checks it belongs to sort.
+func DecodeCursor(s string, sort string) (*Cursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, constants.NewValidationError("Invalid cursor: %s", s)
+	}
+
+	c := &Cursor{}
+	if err := json.Unmarshal(buf, c); err != nil || c.Sort != sort {
+		return nil, constants.NewValidationError("Invalid cursor: %s", s)
+	}
+	return c, nil
+}
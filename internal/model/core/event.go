@@ -0,0 +1,75 @@
+package core
+
+import (
+	"SYBD/internal/constants"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventThreadCreated = "thread.created"
+	EventPostCreated   = "post.created"
+	EventPostUpdated   = "post.updated"
+	EventThreadVotes   = "thread.votes"
+)
+
+// Event is a change streamed to the subscribers of a thread or a forum. Post
+// is set for post events and holds the post as it is when the event is sent,
+// Details likewise holds the thread of thread.created events; Votes is set for
+// vote events and holds the thread votes after the change.
+type Event struct {
+	ID int64 `json:"id"`
+	// TxID is the transaction which added the event.
+	TxID int64 `json:"-"`
+	// Cursor is the position of the event, which clients resume after.
+	Cursor  string    `json:"cursor"`
+	Type    string    `json:"type"`
+	Forum   string    `json:"forum"`
+	Thread  int64     `json:"thread"`
+	PostID  int64     `json:"-"`
+	Post    *Post     `json:"post,omitempty"`
+	Details *Thread   `json:"details,omitempty"`
+	Votes   *int64    `json:"votes,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// EventPosition is the place of an event in the log. Events are read in
+// (TxID, ID) order once no running transaction can add an event before them,
+// so reading after a position never misses an event committed late.
+type EventPosition struct {
+	TxID int64
+	ID   int64
+}
+
+func (e *Event) Position() EventPosition {
+	return EventPosition{TxID: e.TxID, ID: e.ID}
+}
+
+// After reports whether p follows q in the log.
+func (p EventPosition) After(q EventPosition) bool {
+	return p.TxID > q.TxID || p.TxID == q.TxID && p.ID > q.ID
+}
+
+func (p EventPosition) IsZero() bool {
+	return p == EventPosition{}
+}
+
+// String returns the cursor handed out to clients.
+func (p EventPosition) String() string {
+	return fmt.Sprintf("%d-%d", p.TxID, p.ID)
+}
+
+// ParseEventPosition parses a cursor returned by EventPosition.String.
+func ParseEventPosition(s string) (EventPosition, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) == 2 {
+		txID, txErr := strconv.ParseInt(parts[0], 10, 64)
+		id, idErr := strconv.ParseInt(parts[1], 10, 64)
+		if txErr == nil && idErr == nil && txID >= 0 && id >= 0 {
+			return EventPosition{TxID: txID, ID: id}, nil
+		}
+	}
+	return EventPosition{}, constants.NewValidationError("Invalid last event id: %s", s)
+}
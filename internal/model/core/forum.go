@@ -6,4 +6,20 @@ type Forum struct {
 	Slug    string `json:"slug"`
 	Posts   int64  `json:"posts"`
 	Threads int64  `json:"threads"`
+
+	Description string `json:"description,omitempty"`
+
+	// VoteMin and VoteMax bound the voice of a vote on the threads of the forum.
+	VoteMin int64 `json:"voteMin"`
+	VoteMax int64 `json:"voteMax"`
+}
+
+const (
+	DefaultVoteMin = -1
+	DefaultVoteMax = 1
+)
+
+// AcceptsVoice reports whether voice is a valid vote on the forum's threads.
+func (f *Forum) AcceptsVoice(voice int64) bool {
+	return voice != 0 && voice >= f.VoteMin && voice <= f.VoteMax
 }
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	NotificationMention = "mention"
+	NotificationReply   = "reply"
+)
+
+// MaxMentions is how many users a single post can notify by mentions.
+const MaxMentions = 20
+
+// Notification tells a user about a post mentioning them or replying to one
+// of their posts.
+type Notification struct {
+	ID       int64     `json:"id"`
+	Nickname string    `json:"-"`
+	Kind     string    `json:"kind"`
+	PostID   int64     `json:"-"`
+	Post     *Post     `json:"post"`
+	Read     bool      `json:"read"`
+	Created  time.Time `json:"created"`
+}
+
+// mentionPattern matches @nickname not preceded by a nickname character, so
+// e-mail addresses are not taken for mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9_.]+)`)
+
+// Mentions returns the nicknames mentioned in message, first occurrence
+// first, up to MaxMentions. A trailing dot ends the sentence, not the
+// nickname.
+func Mentions(message string) []string {
+	seen := make(map[string]bool)
+	mentions := make([]string, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatch(message, -1) {
+		nickname := strings.TrimRight(match[1], ".")
+		if nickname == "" || seen[strings.ToLower(nickname)] {
+			continue
+		}
+		seen[strings.ToLower(nickname)] = true
+		mentions = append(mentions, nickname)
+		if len(mentions) == MaxMentions {
+			break
+		}
+	}
+	return mentions
+}
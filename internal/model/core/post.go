@@ -3,12 +3,25 @@ package core
 import "time"
 
 type Post struct {
-	ID       int64     `json:"id"`
-	Pred     int64     `json:"parent"`
-	Author   string    `json:"author"`
-	Message  string    `json:"message"`
-	IsEdited bool      `json:"isEdited"`
-	Forum    string    `json:"forum"`
-	Thread   int64     `json:"thread"`
-	Created  time.Time `json:"created"`
+	ID        int64     `json:"id"`
+	Pred      int64     `json:"parent"`
+	Author    string    `json:"author"`
+	Message   string    `json:"message"`
+	IsEdited  bool      `json:"isEdited"`
+	Forum     string    `json:"forum"`
+	Thread    int64     `json:"thread"`
+	Created   time.Time `json:"created"`
+	IsDeleted bool      `json:"isDeleted,omitempty"`
+	Score     int64     `json:"score"`
+}
+
+const DeletedPostMessage = "[deleted]"
+
+// Tombstone hides the message and author of a deleted post. The post keeps
+// its id and path, so its replies still render in tree sorts.
+func (p *Post) Tombstone() {
+	if p.IsDeleted {
+		p.Message = DeletedPostMessage
+		p.Author = ""
+	}
 }
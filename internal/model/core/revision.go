@@ -0,0 +1,76 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// PostRevision is a message a post had before one of its edits. Editor and
+// Created describe the edit which replaced it.
+type PostRevision struct {
+	Revision int64      `json:"revision"`
+	Post     int64      `json:"post"`
+	Message  string     `json:"message"`
+	Editor   string     `json:"editor"`
+	Created  time.Time  `json:"created"`
+	Diff     []DiffLine `json:"diff,omitempty"`
+}
+
+const (
+	DiffEqual  = "="
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines returns the line diff turning a into b, based on the longest
+// common subsequence of their lines.
+func DiffLines(a string, b string) []DiffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(x)+len(y))
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: x[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: y[j]})
+	}
+	return diff
+}
@@ -0,0 +1,33 @@
+package core
+
+import "time"
+
+const (
+	SearchHitPost   = "post"
+	SearchHitThread = "thread"
+)
+
+// SearchQuery describes a full-text search. Zero values disable a filter.
+type SearchQuery struct {
+	Text   string
+	Type   string
+	Forum  string
+	Thread int64
+	Author string
+	Since  time.Time
+	Until  time.Time
+	Limit  int64
+	After  *Cursor
+}
+
+type SearchHit struct {
+	Type    string    `json:"type"`
+	ID      int64     `json:"id"`
+	Thread  int64     `json:"thread"`
+	Forum   string    `json:"forum"`
+	Author  string    `json:"author"`
+	Title   string    `json:"title,omitempty"`
+	Snippet string    `json:"snippet"`
+	Rank    float64   `json:"rank"`
+	Created time.Time `json:"created"`
+}
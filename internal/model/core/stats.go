@@ -0,0 +1,56 @@
+package core
+
+import "time"
+
+// Windows of the forum stats.
+const (
+	StatsWindowDay  = "day"
+	StatsWindowWeek = "week"
+	StatsWindowAll  = "all"
+)
+
+// Buckets of the forum activity, the units accepted by date_trunc.
+const (
+	StatsBucketHour  = "hour"
+	StatsBucketDay   = "day"
+	StatsBucketMonth = "month"
+)
+
+// ForumStats summarizes a forum since the start of a window. Deleted posts
+// are not counted.
+type ForumStats struct {
+	Forum  string `json:"forum"`
+	Window string `json:"window"`
+	// Since is nil for the all-time window.
+	Since      *time.Time       `json:"since,omitempty"`
+	Bucket     string           `json:"bucket"`
+	Activity   []*ForumActivity `json:"activity"`
+	TopPosters []*ForumPoster   `json:"topPosters"`
+	TopThreads []*Thread        `json:"topThreads"`
+}
+
+// ForumActivity counts the posts and threads added in the bucket starting
+// at Start.
+type ForumActivity struct {
+	Start   time.Time `json:"start"`
+	Posts   int64     `json:"posts"`
+	Threads int64     `json:"threads"`
+}
+
+type ForumPoster struct {
+	Nickname string `json:"nickname"`
+	Posts    int64  `json:"posts"`
+}
+
+// StatsBucketStart truncates t in UTC to the start of its bucket, like
+// date_trunc does.
+func StatsBucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case StatsBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case StatsBucketDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
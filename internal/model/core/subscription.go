@@ -0,0 +1,19 @@
+package core
+
+import "time"
+
+const (
+	SubscriptionThread = "thread"
+	SubscriptionForum  = "forum"
+)
+
+// Subscription is a thread or forum followed by a user. New posts in it,
+// numbered above Since, make up the feed of the user.
+type Subscription struct {
+	Nickname string    `json:"-"`
+	Kind     string    `json:"kind"`
+	Thread   int64     `json:"thread,omitempty"`
+	Forum    string    `json:"forum,omitempty"`
+	Since    int64     `json:"-"`
+	Created  time.Time `json:"created"`
+}
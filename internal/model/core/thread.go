@@ -1,6 +1,9 @@
 package core
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type Thread struct {
 	ID      int64     `json:"id"`
@@ -11,4 +14,62 @@ type Thread struct {
 	Votes   int64     `json:"votes"`
 	Slug    string    `json:"slug"`
 	Created time.Time `json:"created"`
+	State   string    `json:"state"`
+	// Active and Hot are the keys of the active and hot sorts, only read
+	// when threads are listed.
+	Active time.Time `json:"-"`
+	Hot    float64   `json:"-"`
+}
+
+// Sorts of forum threads.
+const (
+	ThreadSortNew    = "new"
+	ThreadSortTop    = "top"
+	ThreadSortActive = "active"
+	ThreadSortHot    = "hot"
+)
+
+// HotScore mirrors the hot_score SQL function: votes decayed by age, every
+// tenfold increase in votes being worth 12.5 hours.
+func HotScore(votes int64, created time.Time) float64 {
+	order := math.Log10(math.Max(math.Abs(float64(votes)), 1))
+	sign := 0.0
+	switch {
+	case votes > 0:
+		sign = 1
+	case votes < 0:
+		sign = -1
+	}
+	return sign*order + float64(created.UnixNano())/1e9/45000
+}
+
+const (
+	ThreadOpen     = "open"
+	ThreadClosed   = "closed"
+	ThreadLocked   = "locked"
+	ThreadArchived = "archived"
+)
+
+func ValidThreadState(state string) bool {
+	switch state {
+	case ThreadOpen, ThreadClosed, ThreadLocked, ThreadArchived:
+		return true
+	}
+	return false
+}
+
+// AcceptsPosts reports whether new posts may be added to the thread.
+func (t *Thread) AcceptsPosts() bool {
+	return t.State == ThreadOpen
+}
+
+// AcceptsVotes reports whether votes on the thread may change. Closed threads
+// take no new posts but remain votable.
+func (t *Thread) AcceptsVotes() bool {
+	return t.State == ThreadOpen || t.State == ThreadClosed
+}
+
+// AcceptsEdits reports whether the thread and its posts may be edited.
+func (t *Thread) AcceptsEdits() bool {
+	return t.State == ThreadOpen || t.State == ThreadClosed
 }
@@ -1,8 +1,57 @@
 package core
 
+import (
+	"fmt"
+	"strings"
+)
+
 type User struct {
 	FullName string `json:"fullname"`
 	Nickname string `json:"nickname"`
 	About    string `json:"about"`
 	Email    string `json:"email"`
 }
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	UserDeleteAnonymize = "anonymize"
+	UserDeletePurge     = "purge"
+)
+
+// DeletedUserFullName is the full name of the placeholder account which takes
+// over the content of a deleted user.
+const DeletedUserFullName = "Deleted user"
+
+// UserDeletion reports what deleting a user changes. In anonymize mode the
+// counters are rows re-attributed to the placeholder, in purge mode rows
+// removed; Tombstoned posts are purged posts kept because others replied.
+// Purging creates the placeholder only for tombstoned posts, edited revisions
+// and owned forums, and leaves Placeholder empty without any.
+type UserDeletion struct {
+	Nickname    string `json:"nickname"`
+	Placeholder string `json:"placeholder"`
+	Mode        string `json:"mode"`
+	DryRun      bool   `json:"dryRun"`
+	Threads     int64  `json:"threads"`
+	Posts       int64  `json:"posts"`
+	Tombstoned  int64  `json:"tombstoned"`
+	Votes       int64  `json:"votes"`
+	Forums      int64  `json:"forums"`
+}
+
+// DeletedUserPrefix starts the nicknames of placeholders, which are reserved.
+const DeletedUserPrefix = "deleted-"
+
+// DeletedUserNickname returns the placeholder nickname for the user with id.
+func DeletedUserNickname(id int64) string {
+	return fmt.Sprintf("%s%d", DeletedUserPrefix, id)
+}
+
+// IsDeletedUserNickname reports whether nickname is reserved for placeholders.
+func IsDeletedUserNickname(nickname string) bool {
+	return strings.HasPrefix(strings.ToLower(nickname), DeletedUserPrefix)
+}
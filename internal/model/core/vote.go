@@ -1,7 +1,13 @@
 package core
 
 type Vote struct {
+	Nickname string `json:"nickname"`
+	ThreadID int64  `json:"-"`
+	Voice    int64  `json:"voice"`
+}
+
+type PostVote struct {
 	Nickname string
-	ThreadID int64
+	PostID   int64
 	Voice    int64
 }
@@ -0,0 +1,69 @@
+package core
+
+import "time"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookEvents are the event types webhooks can be registered for.
+var WebhookEvents = []string{EventThreadCreated, EventPostCreated, EventPostUpdated, EventThreadVotes}
+
+// Webhook is a URL events of a forum are posted to. Secret signs the payloads
+// and is only shown when the webhook is created.
+type Webhook struct {
+	ID      int64     `json:"id"`
+	Forum   string    `json:"forum"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret,omitempty"`
+	Events  []string  `json:"events"`
+	Created time.Time `json:"created"`
+}
+
+func (w *Webhook) Accepts(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an event queued for a webhook. URL and Secret are those
+// of the webhook, set on deliveries claimed for sending.
+type WebhookDelivery struct {
+	ID          int64             `json:"id"`
+	Webhook     int64             `json:"webhook"`
+	Event       int64             `json:"event"`
+	Type        string            `json:"type"`
+	Payload     string            `json:"payload"`
+	Status      string            `json:"status"`
+	Attempts    int64             `json:"attempts"`
+	NextAttempt *time.Time        `json:"next_attempt,omitempty"`
+	Created     time.Time         `json:"created"`
+	History     []*WebhookAttempt `json:"history"`
+	URL         string            `json:"-"`
+	Secret      string            `json:"-"`
+}
+
+// WebhookAttempt is the outcome of one request of a delivery. Code is the
+// response status, zero when no response was received.
+type WebhookAttempt struct {
+	Delivery int64     `json:"-"`
+	Attempt  int64     `json:"attempt"`
+	Code     int       `json:"code,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Duration int64     `json:"duration_ms"`
+	Created  time.Time `json:"created"`
+}
+
+func ValidWebhookEvent(eventType string) bool {
+	for _, e := range WebhookEvents {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type LoginRequest struct {
+	Nickname string `json:"nickname"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type Token struct {
+	Nickname string    `json:"nickname"`
+	Token    string    `json:"token"`
+	Expires  time.Time `json:"expires"`
+}
+
+type SetPasswordRequest struct {
+	Nickname string `path:"nickname"`
+	Password string `json:"password"`
+}
+
+type SetPasswordResponse struct {
+	Value interface{}
+	Code  int
+}
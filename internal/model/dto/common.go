@@ -3,6 +3,20 @@ package dto
 type BasicResponse struct{}
 
 type ErrorResponse struct {
+	Message string      `json:"message"`
+	Code    int         `json:"code"`
+	Errors  []ItemError `json:"errors,omitempty"`
+}
+
+// ItemError reports which item of a batch request failed and why.
+type ItemError struct {
+	Index   int    `json:"index"`
 	Message string `json:"message"`
 	Code    int    `json:"code"`
 }
+
+// Page is the envelope of cursor-paginated listings.
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
@@ -1,9 +1,12 @@
 package dto
 
 type CreateForumRequest struct {
-	Title string `json:"title"`
-	User  string `json:"user"`
-	Slug  string `json:"slug"`
+	Title       string `json:"title"`
+	User        string `json:"user"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	VoteMin     *int64 `json:"voteMin"`
+	VoteMax     *int64 `json:"voteMax"`
 }
 
 type CreateForumResponse struct {
@@ -21,25 +24,80 @@ type GetForumResponse struct {
 }
 
 type GetForumThreadRequest struct {
-	Slug  string `path:"slug"`
-	Limit int64  `query:"limit"`
-	Since string `query:"since"`
-	Desc  bool   `query:"desc"`
+	Slug   string `path:"slug"`
+	Limit  int64  `query:"limit"`
+	Since  string `query:"since"`
+	Cursor string `query:"cursor"`
+	Desc   bool   `query:"desc"`
+	Sort   string `query:"sort"`
 }
 
 type GetForumThreadResponse struct {
-	Value interface{}
-	Code  int
+	Value      interface{}
+	Code       int
+	NextCursor string
 }
 
 type GetForumUsersRequest struct {
-	Slug  string `path:"slug"`
-	Limit int64  `query:"limit"`
-	Since string `query:"since"`
-	Desc  bool   `query:"desc"`
+	Slug   string `path:"slug"`
+	Limit  int64  `query:"limit"`
+	Since  string `query:"since"`
+	Cursor string `query:"cursor"`
+	Desc   bool   `query:"desc"`
 }
 
 type GetForumUsersResponse struct {
+	Value      interface{}
+	Code       int
+	NextCursor string
+}
+
+type UpdateForumRequest struct {
+	Slug        string `path:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	User        string `json:"user"`
+	VoteMin     *int64 `json:"voteMin"`
+	VoteMax     *int64 `json:"voteMax"`
+}
+
+type UpdateForumResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type DeleteForumRequest struct {
+	Slug    string `path:"slug"`
+	Cascade bool   `query:"cascade"`
+}
+
+type DeleteForumResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetForumStatsRequest struct {
+	Slug   string `path:"slug"`
+	Window string `query:"window"`
+	Limit  int64  `query:"limit"`
+}
+
+type GetForumStatsResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetModeratorsResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type ModeratorRequest struct {
+	Slug     string `path:"slug"`
+	Nickname string `path:"nickname"`
+}
+
+type ModeratorResponse struct {
 	Value interface{}
 	Code  int
 }
@@ -0,0 +1,37 @@
+package dto
+
+type GetNotificationsRequest struct {
+	Nickname string `path:"nickname"`
+	Unread   bool   `query:"unread"`
+	Limit    int64  `query:"limit"`
+	Cursor   string `query:"cursor"`
+}
+
+type GetNotificationsResponse struct {
+	Value      interface{}
+	Code       int
+	NextCursor string
+}
+
+// NotificationPage is a page of notifications with the unread count of the
+// user.
+type NotificationPage struct {
+	Unread     int64       `json:"unread"`
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type MarkReadRequest struct {
+	Nickname string  `path:"nickname"`
+	IDs      []int64 `json:"ids"`
+}
+
+type MarkReadResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type MarkReadResult struct {
+	Marked int64 `json:"marked"`
+	Unread int64 `json:"unread"`
+}
@@ -13,9 +13,19 @@ type CreatePostResponse struct {
 	Code  int
 }
 
+type GetPostRequest struct {
+	SlugOrID string
+	Sort     string
+	Since    int64
+	Cursor   string
+	Desc     bool
+	Limit    int64
+}
+
 type GetPostResponse struct {
-	Value interface{}
-	Code  int
+	Value      interface{}
+	Code       int
+	NextCursor string
 }
 
 type PostInfo struct {
@@ -38,9 +48,63 @@ type GetPostDetailsResponse struct {
 type UpdatePostRequest struct {
 	ID      int64  `path:"id"`
 	Message string `json:"message"`
+	Editor  string `json:"editor"`
 }
 
 type UpdatePostResponse struct {
 	Value interface{}
 	Code  int
 }
+
+type UpdatePostVoteRequest struct {
+	ID       int64  `path:"id"`
+	Nickname string `json:"nickname"`
+	Voice    int64  `json:"voice"`
+}
+
+type UpdatePostVoteResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type DeletePostRequest struct {
+	ID int64 `path:"id"`
+}
+
+type DeletePostResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type DeletedPosts struct {
+	Deleted int64 `json:"deleted"`
+}
+
+type RestorePostRequest struct {
+	ID int64 `path:"id"`
+}
+
+type RestorePostResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetPostHistoryRequest struct {
+	ID int64 `path:"id"`
+}
+
+type GetPostHistoryResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type RollbackPostRequest struct {
+	ID       int64  `path:"id"`
+	Revision int64  `json:"revision"`
+	Editor   string `json:"editor"`
+}
+
+type RollbackPostResponse struct {
+	Value interface{}
+	Code  int
+}
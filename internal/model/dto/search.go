@@ -0,0 +1,19 @@
+package dto
+
+type SearchRequest struct {
+	Query  string `query:"q"`
+	Type   string `query:"type"`
+	Forum  string `query:"forum"`
+	Thread string `query:"thread"`
+	Author string `query:"author"`
+	Since  string `query:"since"`
+	Until  string `query:"until"`
+	Limit  int64  `query:"limit"`
+	Cursor string `query:"cursor"`
+}
+
+type SearchResponse struct {
+	Value      interface{}
+	Code       int
+	NextCursor string
+}
@@ -0,0 +1,16 @@
+package dto
+
+type StatusResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type ClearResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type MetricsResponse struct {
+	Value interface{}
+	Code  int
+}
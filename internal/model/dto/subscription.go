@@ -0,0 +1,41 @@
+package dto
+
+type GetSubscriptionsRequest struct {
+	Nickname string `path:"nickname"`
+}
+
+type GetSubscriptionsResponse struct {
+	Value interface{}
+	Code  int
+}
+
+// SubscriptionRequest names the thread (slug or id) or forum (slug) in
+// Target, depending on Kind.
+type SubscriptionRequest struct {
+	Nickname string
+	Kind     string
+	Target   string
+}
+
+type SubscriptionResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetFeedRequest struct {
+	Nickname string `path:"nickname"`
+	Limit    int64  `query:"limit"`
+	Peek     bool   `query:"peek"`
+}
+
+type GetFeedResponse struct {
+	Value interface{}
+	Code  int
+}
+
+// FeedPage holds the new posts of a feed; More is set when there are newer
+// posts than those in Items.
+type FeedPage struct {
+	Items interface{} `json:"items"`
+	More  bool        `json:"more"`
+}
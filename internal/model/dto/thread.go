@@ -26,6 +26,28 @@ type UpdateVoteResponse struct {
 	Code  int
 }
 
+type GetVotesRequest struct {
+	SlugOrID string `path:"slug_or_id"`
+	Limit    int64  `query:"limit"`
+	Cursor   string `query:"cursor"`
+}
+
+type GetVotesResponse struct {
+	Value      interface{}
+	Code       int
+	NextCursor string
+}
+
+type DeleteVoteRequest struct {
+	SlugOrID string `path:"slug_or_id"`
+	Nickname string `path:"nickname"`
+}
+
+type DeleteVoteResponse struct {
+	Value interface{}
+	Code  int
+}
+
 type GetDetailsResponse struct {
 	Value interface{}
 	Code  int
@@ -40,3 +62,12 @@ type UpdateThreadResponse struct {
 	Value interface{}
 	Code  int
 }
+
+type UpdateThreadStateRequest struct {
+	State string `json:"state"`
+}
+
+type UpdateThreadStateResponse struct {
+	Value interface{}
+	Code  int
+}
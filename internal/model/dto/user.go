@@ -5,6 +5,7 @@ type CreateUserRequest struct {
 	About    string `json:"about"`
 	Email    string `json:"email"`
 	FullName string `json:"fullname"`
+	Password string `json:"password"`
 }
 
 type CreateUserResponse struct {
@@ -32,3 +33,24 @@ type UpdateProfileResponse struct {
 	Value interface{}
 	Code  int
 }
+
+type DeleteUserRequest struct {
+	Nickname string `path:"nickname"`
+	Mode     string `query:"mode"`
+	DryRun   bool   `query:"dry_run"`
+}
+
+type DeleteUserResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type SetRoleRequest struct {
+	Nickname string `path:"nickname" json:"nickname"`
+	Role     string `json:"role"`
+}
+
+type SetRoleResponse struct {
+	Value interface{}
+	Code  int
+}
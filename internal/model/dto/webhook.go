@@ -0,0 +1,51 @@
+package dto
+
+type CreateWebhookRequest struct {
+	Slug   string   `path:"slug"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+type CreateWebhookResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetWebhooksResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type WebhookRequest struct {
+	Slug string `path:"slug"`
+	ID   int64  `path:"id"`
+}
+
+type WebhookResponse struct {
+	Value interface{}
+	Code  int
+}
+
+type GetDeliveriesRequest struct {
+	Slug   string `path:"slug"`
+	ID     int64  `path:"id"`
+	Limit  int64  `query:"limit"`
+	Cursor string `query:"cursor"`
+}
+
+type GetDeliveriesResponse struct {
+	Value      interface{}
+	Code       int
+	NextCursor string
+}
+
+type RedeliverRequest struct {
+	Slug     string `path:"slug"`
+	ID       int64  `path:"id"`
+	Delivery int64  `path:"delivery"`
+}
+
+type RedeliverResponse struct {
+	Value interface{}
+	Code  int
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"expvar"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rejections counts rejected requests by "<method> <route> <scope>".
+var Rejections = expvar.NewMap("ratelimit_rejections")
+
+// idleTimeout is how long an untouched bucket is kept. Budgets are expected to
+// refill well within it, so dropping a bucket is the same as keeping it full.
+const idleTimeout = 10 * time.Minute
+
+// Budget is a token bucket refilled with Rate tokens per second up to Burst.
+// A zero budget does not limit.
+type Budget struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+func (b Budget) enabled() bool {
+	return b.Rate > 0 && b.Burst > 0
+}
+
+// Rule sets the budgets of one route, per authenticated user and per client IP.
+// A request costs one token, or with PerItem one per element of its JSON
+// array body, e.g. per post of a batch.
+type Rule struct {
+	Method  string `mapstructure:"method"`
+	Path    string `mapstructure:"path"`
+	User    Budget `mapstructure:"user"`
+	IP      Budget `mapstructure:"ip"`
+	PerItem bool   `mapstructure:"per_item"`
+}
+
+type Config struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Default Rule   `mapstructure:"default"`
+	Rules   []Rule `mapstructure:"rules"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per rule, scope and key.
+type Limiter struct {
+	config Config
+	rules  map[string]*Rule
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(config Config) *Limiter {
+	limiter := &Limiter{config: config, rules: make(map[string]*Rule), buckets: make(map[string]*bucket)}
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		limiter.rules[strings.ToUpper(rule.Method)+" "+rule.Path] = rule
+	}
+	return limiter
+}
+
+// Rule returns the rule for a route, the default rule for other writes and
+// nil when the route is not limited.
+func (l *Limiter) Rule(method string, path string) *Rule {
+	if !l.config.Enabled {
+		return nil
+	}
+	if rule, ok := l.rules[method+" "+path]; ok {
+		return rule
+	}
+	switch method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return &l.config.Default
+	}
+	return nil
+}
+
+// Allow takes cost tokens from the user bucket, when nickname is set, and from
+// the IP bucket of rule. Either both or none are taken; when none are, Allow
+// returns how long to wait for the emptier bucket. A request never costs more
+// than the burst, so a larger one empties the bucket instead of never passing.
+func (l *Limiter) Allow(route string, rule *Rule, nickname string, ip string, cost int, now time.Time) (bool, time.Duration) {
+	type check struct {
+		scope  string
+		key    string
+		budget Budget
+	}
+	checks := make([]check, 0, 2)
+	if nickname != "" && rule.User.enabled() {
+		checks = append(checks, check{scope: "user", key: strings.ToLower(nickname), budget: rule.User})
+	}
+	if ip != "" && rule.IP.enabled() {
+		checks = append(checks, check{scope: "ip", key: ip, budget: rule.IP})
+	}
+	if len(checks) == 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	var wait time.Duration
+	buckets := make([]*bucket, len(checks))
+	costs := make([]float64, len(checks))
+	for i, c := range checks {
+		id := route + "|" + c.scope + "|" + c.key
+		b, ok := l.buckets[id]
+		if !ok {
+			b = &bucket{tokens: float64(c.budget.Burst), last: now}
+			l.buckets[id] = b
+		}
+		b.tokens = math.Min(float64(c.budget.Burst), b.tokens+now.Sub(b.last).Seconds()*c.budget.Rate)
+		b.last = now
+		buckets[i] = b
+		costs[i] = math.Max(1, math.Min(float64(cost), float64(c.budget.Burst)))
+
+		if b.tokens < costs[i] {
+			if w := time.Duration((costs[i] - b.tokens) / c.budget.Rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+			Rejections.Add(route+" "+c.scope, 1)
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for i, b := range buckets {
+		b.tokens -= costs[i]
+	}
+	return true, 0
+}
+
+// sweep drops buckets which have been idle long enough to be full again.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for id, b := range l.buckets {
+		if now.Sub(b.last) > idleTimeout {
+			delete(l.buckets, id)
+		}
+	}
+}
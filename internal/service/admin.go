@@ -0,0 +1,67 @@
+package service
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/model/dto"
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// AdminService backs the /service endpoints, which are restricted to admins.
+type AdminService interface {
+	Status(ctx context.Context) (*dto.StatusResponse, error)
+	Clear(ctx context.Context) (*dto.ClearResponse, error)
+	Metrics(ctx context.Context) (*dto.MetricsResponse, error)
+}
+
+type adminServiceImpl struct {
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
+}
+
+func (svc *adminServiceImpl) Status(ctx context.Context) (*dto.StatusResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	status, err := svc.db.ServiceRepo.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.StatusResponse{Value: status, Code: http.StatusOK}, nil
+}
+
+func (svc *adminServiceImpl) Clear(ctx context.Context) (*dto.ClearResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := svc.db.ServiceRepo.Delete(ctx); err != nil {
+		return nil, err
+	}
+	svc.threads.Purge()
+	svc.log.Warn("all data cleared")
+	return &dto.ClearResponse{Value: nil, Code: http.StatusOK}, nil
+}
+
+// Metrics returns the variables published through expvar.
+func (svc *adminServiceImpl) Metrics(ctx context.Context) (*dto.MetricsResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]json.RawMessage)
+	expvar.Do(func(kv expvar.KeyValue) {
+		metrics[kv.Key] = json.RawMessage(kv.Value.String())
+	})
+	return &dto.MetricsResponse{Value: metrics, Code: http.StatusOK}, nil
+}
+
+func NewAdminService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) AdminService {
+	return &adminServiceImpl{log: log, db: db, policy: policy, threads: threads}
+}
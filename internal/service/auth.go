@@ -0,0 +1,107 @@
+package service
+
+import (
+	"SYBD/internal/auth"
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidCredentials = constants.NewUnauthorizedError("Invalid nickname or password")
+	errLoginDisabled      = constants.CreateNewError("Token login is not configured", http.StatusServiceUnavailable)
+)
+
+type AuthService interface {
+	Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error)
+	SetPassword(ctx context.Context, request *dto.SetPasswordRequest) (*dto.SetPasswordResponse, error)
+}
+
+type authServiceImpl struct {
+	log    *logrus.Entry
+	db     *db.Repository
+	policy Policy
+	signer *auth.Signer
+}
+
+func (svc *authServiceImpl) Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error) {
+	if !svc.signer.Enabled() {
+		return nil, errLoginDisabled
+	}
+
+	// Placeholders of deleted users are nobody's to sign in as.
+	if core.IsDeletedUserNickname(request.Nickname) {
+		return nil, errInvalidCredentials
+	}
+
+	nickname, hash, err := svc.db.UserRepo.GetPasswordHash(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+	if !auth.CheckPassword(hash, request.Password) {
+		return nil, errInvalidCredentials
+	}
+
+	id, err := svc.db.UserRepo.GetUserID(ctx, nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+
+	token, expires, err := svc.signer.Issue(nickname, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{Value: &dto.Token{Nickname: nickname, Token: token, Expires: expires}, Code: http.StatusOK}, nil
+}
+
+// SetPassword changes the password of the signed in user. Admins may set the
+// password of any account, e.g. the first one of an account created before
+// passwords, since nothing else proves who owns it.
+func (svc *authServiceImpl) SetPassword(ctx context.Context, request *dto.SetPasswordRequest) (*dto.SetPasswordResponse, error) {
+	if len(request.Password) < auth.MinPasswordLength {
+		return nil, constants.NewValidationError("Password must be at least %d characters long", auth.MinPasswordLength)
+	}
+
+	nickname, _, err := svc.db.UserRepo.GetPasswordHash(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	if core.IsDeletedUserNickname(nickname) {
+		return nil, constants.NewForbiddenError("Placeholders of deleted users can't have a password")
+	}
+
+	if caller := auth.CallerFrom(ctx); caller.Nickname == "" || !strings.EqualFold(caller.Nickname, nickname) {
+		if err := svc.policy.RequireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.db.UserRepo.SetPasswordHash(ctx, nickname, hash); err != nil {
+		return nil, err
+	}
+	return &dto.SetPasswordResponse{Value: dto.BasicResponse{}, Code: http.StatusOK}, nil
+}
+
+func NewAuthService(log *logrus.Entry, db *db.Repository, policy Policy, signer *auth.Signer) AuthService {
+	return &authServiceImpl{log: log, db: db, policy: policy, signer: signer}
+}
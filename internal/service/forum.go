@@ -1,15 +1,17 @@
 package service
 
 import (
+	"SYBD/internal/auth"
 	"SYBD/internal/constants"
 	"SYBD/internal/db"
 	"SYBD/internal/model/core"
 	"SYBD/internal/model/dto"
 	"context"
 	"errors"
-	"fmt"
 	"github.com/sirupsen/logrus"
+	"math"
 	"net/http"
+	"time"
 )
 
 type ForumService interface {
@@ -17,11 +19,19 @@ type ForumService interface {
 	GetForum(ctx context.Context, request *dto.GetForumRequest) (*dto.GetForumResponse, error)
 	GetThread(ctx context.Context, request *dto.GetForumThreadRequest) (*dto.GetForumThreadResponse, error)
 	GetUsers(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.GetForumUsersResponse, error)
+	UpdateForum(ctx context.Context, request *dto.UpdateForumRequest) (*dto.UpdateForumResponse, error)
+	DeleteForum(ctx context.Context, request *dto.DeleteForumRequest) (*dto.DeleteForumResponse, error)
+	GetModerators(ctx context.Context, request *dto.GetForumRequest) (*dto.GetModeratorsResponse, error)
+	AddModerator(ctx context.Context, request *dto.ModeratorRequest) (*dto.ModeratorResponse, error)
+	RemoveModerator(ctx context.Context, request *dto.ModeratorRequest) (*dto.ModeratorResponse, error)
+	GetStats(ctx context.Context, request *dto.GetForumStatsRequest) (*dto.GetForumStatsResponse, error)
 }
 
 type forumServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
 }
 
 func (svc *forumServiceImpl) CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*dto.CreateForumResponse, error) {
@@ -33,15 +43,26 @@ func (svc *forumServiceImpl) CreateForum(ctx context.Context, request *dto.Creat
 		return &dto.CreateForumResponse{Value: forum, Code: http.StatusConflict}, nil
 	}
 
-	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.User)
+	owner, err := auth.Actor(ctx, request.User)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := svc.db.UserRepo.GetUserByNickname(ctx, owner)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.CreateForumResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.User)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", owner)
 		}
+		return nil, err
 	}
 	request.User = user.Nickname
 
-	if err := svc.db.ForumRepo.CreateForum(ctx, &core.Forum{Title: request.Title, User: request.User, Slug: request.Slug}); err != nil {
+	voteMin, voteMax, err := voteRange(request.VoteMin, request.VoteMax, core.DefaultVoteMin, core.DefaultVoteMax)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.db.ForumRepo.CreateForum(ctx, &core.Forum{Title: request.Title, User: request.User, Slug: request.Slug, Description: request.Description, VoteMin: voteMin, VoteMax: voteMax}); err != nil {
 		return nil, err
 	}
 
@@ -58,7 +79,7 @@ func (svc *forumServiceImpl) GetForum(ctx context.Context, request *dto.GetForum
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
 			//svc.log.Errorf("err: %s", err)
-			return &dto.GetForumResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
 		}
 		return nil, err
 	}
@@ -69,45 +90,321 @@ func (svc *forumServiceImpl) GetForum(ctx context.Context, request *dto.GetForum
 func (svc *forumServiceImpl) GetThread(ctx context.Context, request *dto.GetForumThreadRequest) (*dto.GetForumThreadResponse, error) {
 	if forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Slug); err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetForumThreadResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
 		}
+		return nil, err
 	} else {
 		request.Slug = forum.Slug
 	}
 
+	if request.Sort == "" {
+		request.Sort = core.ThreadSortNew
+	}
+	kind, ok := threadCursorKinds[request.Sort]
+	if !ok {
+		return nil, constants.NewValidationError("Invalid sort: %s", request.Sort)
+	}
+
+	after, err := forumThreadsCursor(request, kind)
+	if err != nil {
+		return nil, err
+	}
+
 	threads, err := svc.db.ForumRepo.GetThreadsFromForum(ctx,
 		request.Slug,
+		request.Sort,
 		request.Limit,
-		request.Since,
+		after,
 		request.Desc)
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.GetForumThreadResponse{Value: threads, Code: http.StatusOK}, nil
+	response := &dto.GetForumThreadResponse{Value: threads, Code: http.StatusOK}
+	if request.Limit > 0 && int64(len(threads)) == request.Limit {
+		last := threads[len(threads)-1]
+		next := &core.Cursor{Sort: kind, ID: last.ID}
+		switch request.Sort {
+		case core.ThreadSortTop:
+			next.Score = float64(last.Votes)
+		case core.ThreadSortActive:
+			next.Created = last.Active
+		case core.ThreadSortHot:
+			next.Score = last.Hot
+		default:
+			next.Created = last.Created
+		}
+		response.NextCursor = next.Encode()
+	}
+	return response, nil
 }
 
 func (svc *forumServiceImpl) GetUsers(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.GetForumUsersResponse, error) {
 	if forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Slug); err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetForumUsersResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
 		}
+		return nil, err
 	} else {
 		request.Slug = forum.Slug
 	}
 
-	threads, err := svc.db.ForumRepo.GetUsersFromForum(ctx,
+	after, err := forumUsersCursor(request)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := svc.db.ForumRepo.GetUsersFromForum(ctx,
 		request.Slug,
 		request.Limit,
-		request.Since,
+		after,
 		request.Desc)
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.GetForumUsersResponse{Value: threads, Code: http.StatusOK}, nil
+	response := &dto.GetForumUsersResponse{Value: users, Code: http.StatusOK}
+	if request.Limit > 0 && int64(len(users)) == request.Limit {
+		last := users[len(users)-1]
+		response.NextCursor = (&core.Cursor{Sort: core.CursorUsers, Nickname: last.Nickname}).Encode()
+	}
+	return response, nil
+}
+
+func (svc *forumServiceImpl) UpdateForum(ctx context.Context, request *dto.UpdateForumRequest) (*dto.UpdateForumResponse, error) {
+	forum, err := svc.managedForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Title == "" {
+		request.Title = forum.Title
+	}
+
+	if request.Description == "" {
+		request.Description = forum.Description
+	}
+
+	if request.User == "" {
+		request.User = forum.User
+	} else {
+		user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.User)
+		if err != nil {
+			if errors.Is(err, constants.ErrDBNotFound) {
+				return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.User)
+			}
+			return nil, err
+		}
+		request.User = user.Nickname
+	}
+
+	voteMin, voteMax, err := voteRange(request.VoteMin, request.VoteMax, forum.VoteMin, forum.VoteMax)
+	if err != nil {
+		return nil, err
+	}
+
+	forum, err = svc.db.ForumRepo.UpdateForum(ctx, forum.Slug, request.Title, request.Description, request.User, voteMin, voteMax)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.UpdateForumResponse{Value: forum, Code: http.StatusOK}, nil
+}
+
+func (svc *forumServiceImpl) DeleteForum(ctx context.Context, request *dto.DeleteForumRequest) (*dto.DeleteForumResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	forum, err := svc.db.ForumRepo.DeleteForum(ctx, request.Slug, request.Cascade)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
+		}
+		return nil, err
+	}
+	svc.threads.Purge()
+	return &dto.DeleteForumResponse{Value: forum, Code: http.StatusOK}, nil
+}
+
+func (svc *forumServiceImpl) GetModerators(ctx context.Context, request *dto.GetForumRequest) (*dto.GetModeratorsResponse, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
+		}
+		return nil, err
+	}
+
+	moderators, err := svc.db.ForumRepo.GetModerators(ctx, forum.Slug)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.GetModeratorsResponse{Value: moderators, Code: http.StatusOK}, nil
+}
+
+func (svc *forumServiceImpl) AddModerator(ctx context.Context, request *dto.ModeratorRequest) (*dto.ModeratorResponse, error) {
+	forum, err := svc.managedForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+
+	if err := svc.db.ForumRepo.AddModerator(ctx, forum.Slug, user.Nickname); err != nil {
+		return nil, err
+	}
+	return &dto.ModeratorResponse{Value: user, Code: http.StatusOK}, nil
+}
+
+func (svc *forumServiceImpl) RemoveModerator(ctx context.Context, request *dto.ModeratorRequest) (*dto.ModeratorResponse, error) {
+	forum, err := svc.managedForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := svc.db.ForumRepo.RemoveModerator(ctx, forum.Slug, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, constants.NewNotFoundError("%s is not a moderator of forum %s", request.Nickname, forum.Slug)
+	}
+	return &dto.ModeratorResponse{Value: dto.BasicResponse{}, Code: http.StatusOK}, nil
+}
+
+// GetStats returns the activity, top posters and top voted threads of a
+// forum over a window, by default the last week.
+func (svc *forumServiceImpl) GetStats(ctx context.Context, request *dto.GetForumStatsRequest) (*dto.GetForumStatsResponse, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Slug)
+		}
+		return nil, err
+	}
+
+	if request.Window == "" {
+		request.Window = core.StatsWindowWeek
+	}
+	window, ok := statsWindows[request.Window]
+	if !ok {
+		return nil, constants.NewValidationError("Invalid window: %s", request.Window)
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultStatsLimit
+	}
+	if request.Limit > maxStatsLimit {
+		request.Limit = maxStatsLimit
+	}
+
+	// The aggregates are hourly, so windows start on the hour.
+	var since time.Time
+	if window.length > 0 {
+		since = time.Now().Add(-window.length).Truncate(time.Hour)
+	}
+
+	stats, err := svc.db.StatsRepo.GetForumStats(ctx, forum.Slug, since, window.bucket, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+	stats.Window = request.Window
+	if !since.IsZero() {
+		stats.Since = &since
+	}
+	return &dto.GetForumStatsResponse{Value: stats, Code: http.StatusOK}, nil
+}
+
+// managedForum returns the forum with slug once the caller may manage it.
+func (svc *forumServiceImpl) managedForum(ctx context.Context, slug string) (*core.Forum, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", slug)
+		}
+		return nil, err
+	}
+
+	if err := svc.policy.CanManageForum(ctx, forum); err != nil {
+		return nil, err
+	}
+	return forum, nil
+}
+
+const (
+	defaultStatsLimit = 10
+	maxStatsLimit     = 100
+)
+
+// statsWindows maps the windows of the forum stats to their length, zero for
+// all time, and the bucket of their activity.
+var statsWindows = map[string]struct {
+	length time.Duration
+	bucket string
+}{
+	core.StatsWindowDay:  {24 * time.Hour, core.StatsBucketHour},
+	core.StatsWindowWeek: {7 * 24 * time.Hour, core.StatsBucketDay},
+	core.StatsWindowAll:  {0, core.StatsBucketMonth},
+}
+
+// threadCursorKinds maps the sorts of forum threads to their cursor kinds.
+var threadCursorKinds = map[string]string{
+	core.ThreadSortNew:    core.CursorThreads,
+	core.ThreadSortTop:    core.CursorThreadsTop,
+	core.ThreadSortActive: core.CursorThreadsActive,
+	core.ThreadSortHot:    core.CursorThreadsHot,
+}
+
+// voteRange fills the unset bounds of a vote range from the current ones and
+// checks the result allows at least one non-zero voice.
+func voteRange(min *int64, max *int64, currentMin int64, currentMax int64) (int64, int64, error) {
+	if min != nil {
+		currentMin = *min
+	}
+	if max != nil {
+		currentMax = *max
+	}
+	if currentMin > currentMax || currentMin == 0 && currentMax == 0 {
+		return 0, 0, constants.NewValidationError("Invalid vote range: %d to %d", currentMin, currentMax)
+	}
+	return currentMin, currentMax, nil
+}
+
+// forumThreadsCursor converts the cursor or the legacy inclusive since timestamp
+// into a keyset position. since only applies to the new sort.
+func forumThreadsCursor(request *dto.GetForumThreadRequest, kind string) (*core.Cursor, error) {
+	if request.Cursor != "" {
+		return core.DecodeCursor(request.Cursor, kind)
+	}
+	if request.Since == "" || request.Sort != core.ThreadSortNew {
+		return nil, nil
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, request.Since)
+	if err != nil {
+		return nil, constants.NewValidationError("Invalid since timestamp: %s", request.Since)
+	}
+	if request.Desc {
+		return &core.Cursor{Sort: core.CursorThreads, Created: since, ID: math.MaxInt32}, nil
+	}
+	return &core.Cursor{Sort: core.CursorThreads, Created: since, ID: 0}, nil
+}
+
+func forumUsersCursor(request *dto.GetForumUsersRequest) (*core.Cursor, error) {
+	if request.Cursor != "" {
+		return core.DecodeCursor(request.Cursor, core.CursorUsers)
+	}
+	if request.Since == "" {
+		return nil, nil
+	}
+	return &core.Cursor{Sort: core.CursorUsers, Nickname: request.Since}, nil
 }
 
-func NewForumService(log *logrus.Entry, db *db.Repository) ForumService {
-	return &forumServiceImpl{log: log, db: db}
+func NewForumService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) ForumService {
+	return &forumServiceImpl{log: log, db: db, policy: policy, threads: threads}
 }
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+const defaultNotifications = 50
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, request *dto.GetNotificationsRequest) (*dto.GetNotificationsResponse, error)
+	MarkRead(ctx context.Context, request *dto.MarkReadRequest) (*dto.MarkReadResponse, error)
+}
+
+type notificationServiceImpl struct {
+	log    *logrus.Entry
+	db     *db.Repository
+	policy Policy
+}
+
+func (svc *notificationServiceImpl) GetNotifications(ctx context.Context, request *dto.GetNotificationsRequest) (*dto.GetNotificationsResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	var before int64
+	if request.Cursor != "" {
+		cursor, err := core.DecodeCursor(request.Cursor, core.CursorNotifications)
+		if err != nil {
+			return nil, err
+		}
+		before = cursor.ID
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultNotifications
+	}
+
+	notifications, err := svc.db.NotifyRepo.GetNotifications(ctx, user.Nickname, request.Unread, before, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := svc.db.NotifyRepo.CountUnread(ctx, user.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.NotificationPage{Unread: unread, Items: notifications}
+	if int64(len(notifications)) == request.Limit {
+		last := notifications[len(notifications)-1]
+		page.NextCursor = (&core.Cursor{Sort: core.CursorNotifications, ID: last.ID}).Encode()
+	}
+	return &dto.GetNotificationsResponse{Value: page, Code: http.StatusOK, NextCursor: page.NextCursor}, nil
+}
+
+func (svc *notificationServiceImpl) MarkRead(ctx context.Context, request *dto.MarkReadRequest) (*dto.MarkReadResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	marked, err := svc.db.NotifyRepo.MarkRead(ctx, user.Nickname, request.IDs)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := svc.db.NotifyRepo.CountUnread(ctx, user.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MarkReadResponse{Value: dto.MarkReadResult{Marked: marked, Unread: unread}, Code: http.StatusOK}, nil
+}
+
+// managedUser returns the user whose own data, such as notifications or
+// subscriptions, the caller may read and change.
+func managedUser(ctx context.Context, policy Policy, repository *db.Repository, nickname string) (*core.User, error) {
+	if err := policy.CanManageUser(ctx, nickname); err != nil {
+		return nil, err
+	}
+
+	user, err := repository.UserRepo.GetUserByNickname(ctx, nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", nickname)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// notifyPosts stores the notifications of new or edited posts: one per user
+// mentioned and, for new replies, one for the author of the parent post.
+// Authors are not notified of their own posts. Failures are logged only, the
+// posts are saved already.
+func notifyPosts(ctx context.Context, log *logrus.Entry, repository *db.Repository, posts []*core.Post, replies bool) {
+	notifications := make([]*core.Notification, 0)
+	parents := make(map[int64]*core.Post)
+	for _, post := range posts {
+		for _, nickname := range core.Mentions(post.Message) {
+			if !strings.EqualFold(nickname, post.Author) {
+				notifications = append(notifications, &core.Notification{Nickname: nickname, Kind: core.NotificationMention, PostID: post.ID})
+			}
+		}
+
+		if !replies || post.Pred == 0 {
+			continue
+		}
+		parent, ok := parents[post.Pred]
+		if !ok {
+			var err error
+			if parent, err = repository.PostRepo.GetPostByID(ctx, post.Pred); err != nil {
+				if !errors.Is(err, constants.ErrDBNotFound) {
+					log.Warnf("can't load parent of post %d: %s", post.ID, err)
+				}
+				parent = nil
+			}
+			parents[post.Pred] = parent
+		}
+		if parent != nil && !parent.IsDeleted && !strings.EqualFold(parent.Author, post.Author) {
+			notifications = append(notifications, &core.Notification{Nickname: parent.Author, Kind: core.NotificationReply, PostID: post.ID})
+		}
+	}
+
+	if err := repository.NotifyRepo.CreateNotifications(ctx, notifications); err != nil {
+		log.Warnf("can't store notifications: %s", err)
+	}
+}
+
+func NewNotificationService(log *logrus.Entry, db *db.Repository, policy Policy) NotificationService {
+	return &notificationServiceImpl{log: log, db: db, policy: policy}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"SYBD/internal/auth"
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"context"
+	"errors"
+	"strings"
+)
+
+// Policy decides whether the caller of a request may perform an action.
+// Admins may do anything. Forum owners (forum.user) manage their forum and
+// its moderators, moderators manage the threads and posts of their forums and
+// authors their own content. Anonymous callers in legacy mode keep the
+// author-level permissions of the legacy API.
+type Policy interface {
+	RequireAdmin(ctx context.Context) error
+	CanEditProfile(ctx context.Context, nickname string) error
+	CanManageUser(ctx context.Context, nickname string) error
+	CanRetractVote(ctx context.Context, nickname string) error
+	CanManageForum(ctx context.Context, forum *core.Forum) error
+	CanModerate(ctx context.Context, forum string) error
+	CanEditThread(ctx context.Context, thread *core.Thread) error
+	CanEditPost(ctx context.Context, post *core.Post) error
+}
+
+type policyImpl struct {
+	db *db.Repository
+}
+
+var errAuthRequired = constants.NewUnauthorizedError("Authentication required")
+
+type subject struct {
+	auth.Caller
+}
+
+func (s *subject) is(nickname string) bool {
+	return s.Nickname != "" && strings.EqualFold(s.Nickname, nickname)
+}
+
+// legacyAnonymous reports whether the caller uses the unauthenticated legacy API.
+func (s *subject) legacyAnonymous() bool {
+	return s.Nickname == "" && s.Legacy
+}
+
+func (p *policyImpl) subject(ctx context.Context) (*subject, error) {
+	s := &subject{Caller: auth.CallerFrom(ctx)}
+	if s.Nickname == "" || s.Admin {
+		return s, nil
+	}
+
+	role, err := p.db.UserRepo.GetUserRole(ctx, s.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewUnauthorizedError("Unknown user: %s", s.Nickname)
+		}
+		return nil, err
+	}
+	s.Admin = role == core.RoleAdmin
+	return s, nil
+}
+
+func (p *policyImpl) RequireAdmin(ctx context.Context) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	if s.Admin {
+		return nil
+	}
+	if s.Nickname == "" {
+		return errAuthRequired
+	}
+	return constants.NewForbiddenError("Admin privileges required")
+}
+
+func (p *policyImpl) CanEditProfile(ctx context.Context, nickname string) error {
+	return p.actAs(ctx, nickname)
+}
+
+// CanRetractVote allows the voter, admins and, like voting itself, legacy
+// anonymous callers to withdraw a vote.
+func (p *policyImpl) CanRetractVote(ctx context.Context, nickname string) error {
+	return p.actAs(ctx, nickname)
+}
+
+func (p *policyImpl) actAs(ctx context.Context, nickname string) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	if s.legacyAnonymous() {
+		return nil
+	}
+	return p.manageUser(s, nickname)
+}
+
+func (p *policyImpl) CanManageUser(ctx context.Context, nickname string) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	return p.manageUser(s, nickname)
+}
+
+func (p *policyImpl) manageUser(s *subject, nickname string) error {
+	if s.Admin || s.is(nickname) {
+		return nil
+	}
+	if s.Nickname == "" {
+		return errAuthRequired
+	}
+	return constants.NewForbiddenError("Can't manage user %s", nickname)
+}
+
+func (p *policyImpl) CanManageForum(ctx context.Context, forum *core.Forum) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	if s.Admin || s.is(forum.User) {
+		return nil
+	}
+	if s.Nickname == "" {
+		return errAuthRequired
+	}
+	return constants.NewForbiddenError("Only the owner can manage forum %s", forum.Slug)
+}
+
+func (p *policyImpl) CanModerate(ctx context.Context, forum string) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	return p.moderate(ctx, s, forum)
+}
+
+func (p *policyImpl) CanEditThread(ctx context.Context, thread *core.Thread) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	if s.legacyAnonymous() || s.is(thread.Author) {
+		return nil
+	}
+	return p.moderate(ctx, s, thread.Forum)
+}
+
+func (p *policyImpl) CanEditPost(ctx context.Context, post *core.Post) error {
+	s, err := p.subject(ctx)
+	if err != nil {
+		return err
+	}
+	if s.legacyAnonymous() || s.is(post.Author) {
+		return nil
+	}
+	return p.moderate(ctx, s, post.Forum)
+}
+
+func (p *policyImpl) moderate(ctx context.Context, s *subject, slug string) error {
+	if s.Admin {
+		return nil
+	}
+	if s.Nickname == "" {
+		return errAuthRequired
+	}
+
+	forum, err := p.db.ForumRepo.GetForumBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if s.is(forum.User) {
+		return nil
+	}
+
+	ok, err := p.db.ForumRepo.IsModerator(ctx, forum.Slug, s.Nickname)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return constants.NewForbiddenError("Moderator privileges required in forum %s", forum.Slug)
+	}
+	return nil
+}
+
+func NewPolicy(db *db.Repository) Policy {
+	return &policyImpl{db: db}
+}
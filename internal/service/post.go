@@ -1,115 +1,206 @@
 package service
 
 import (
+	"SYBD/internal/auth"
 	"SYBD/internal/constants"
 	"SYBD/internal/db"
 	"SYBD/internal/model/core"
 	"SYBD/internal/model/dto"
 	"context"
 	"errors"
-	"fmt"
 	"github.com/sirupsen/logrus"
 	"net/http"
-	"strconv"
+	"strings"
 )
 
+// defaultPosts is the page size of post listings asked for no positive limit.
+const defaultPosts = 100
+
 type PostService interface {
 	CreatePost(ctx context.Context, slugOrID string, posts []*dto.Post) (*dto.CreatePostResponse, error)
-	GetPost(ctx context.Context, slugOrID string, sort string, since int64, desc bool, limit int64) (*dto.GetPostResponse, error)
+	GetPost(ctx context.Context, request *dto.GetPostRequest) (*dto.GetPostResponse, error)
 	GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.GetPostDetailsResponse, error)
 	UpdatePost(ctx context.Context, request *dto.UpdatePostRequest) (*dto.UpdatePostResponse, error)
+	UpdateVote(ctx context.Context, request *dto.UpdatePostVoteRequest) (*dto.UpdatePostVoteResponse, error)
+	GetPostHistory(ctx context.Context, request *dto.GetPostHistoryRequest) (*dto.GetPostHistoryResponse, error)
+	RollbackPost(ctx context.Context, request *dto.RollbackPostRequest) (*dto.RollbackPostResponse, error)
+	DeletePost(ctx context.Context, request *dto.DeletePostRequest) (*dto.DeletePostResponse, error)
+	RestorePost(ctx context.Context, request *dto.RestorePostRequest) (*dto.RestorePostResponse, error)
+	DeletePostTree(ctx context.Context, request *dto.DeletePostRequest) (*dto.DeletePostResponse, error)
 }
 
 type postServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
 }
 
 func (svc *postServiceImpl) CreatePost(ctx context.Context, slugOrID string, posts []*dto.Post) (*dto.CreatePostResponse, error) {
-	var id int
-	var err error
-	id, err = strconv.Atoi(slugOrID)
-
-	var thread *core.Thread
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepo.GetThread(ctx, slugOrID); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.CreatePostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", slugOrID)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
-		}
-	} else {
-		if thread, err = svc.db.ThreadRepo.GetThreadByID(ctx, int64(id)); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.CreatePostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-			}
-		}
+		return nil, err
+	}
+
+	if !thread.AcceptsPosts() {
+		return nil, errThreadState(thread)
 	}
 
 	if len(posts) == 0 {
 		return &dto.CreatePostResponse{Value: []struct{}{}, Code: http.StatusCreated}, nil
 	}
 
-	if posts[0].Parent != 0 {
-		parentThreadID, err := svc.db.PostRepo.CheckPredPost(ctx, int(posts[0].Parent))
-		if err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.CreatePostResponse{Value: dto.ErrorResponse{Message: "Parent post was created in another thread"}, Code: http.StatusConflict}, nil
-			}
-		}
-
-		if parentThreadID != id {
-			return &dto.CreatePostResponse{Value: dto.ErrorResponse{Message: "Parent post was created in another thread"}, Code: http.StatusConflict}, nil
+	for _, post := range posts {
+		if post.Author, err = auth.Actor(ctx, post.Author); err != nil {
+			return nil, err
 		}
 	}
 
-	if _, err := svc.db.UserRepo.GetUserByNickname(ctx, posts[0].Author); err != nil {
-		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.CreatePostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", posts[0].Author)}, Code: http.StatusNotFound}, nil
-		}
+	if err := svc.validatePosts(ctx, thread, posts); err != nil {
+		return nil, err
 	}
 
-	insertedPosts, err := svc.db.PostRepo.CreatePost(ctx, thread.Forum, int64(id), posts)
+	insertedPosts, err := svc.db.PostRepo.CreatePost(ctx, thread.Forum, thread.ID, posts)
 	if err != nil {
 		return nil, err
 	}
+	notifyPosts(ctx, svc.log, svc.db, insertedPosts, true)
+
+	authors := make([]string, 0, len(insertedPosts))
+	for _, post := range insertedPosts {
+		authors = append(authors, post.Author)
+	}
+	subscribeAuthors(ctx, svc.log, svc.db, thread.ID, authors...)
 
 	return &dto.CreatePostResponse{Value: insertedPosts, Code: http.StatusCreated}, nil
 }
 
-func (svc *postServiceImpl) GetPost(ctx context.Context, slugOrID string, sort string, since int64, desc bool, limit int64) (*dto.GetPostResponse, error) {
-	id, err := strconv.Atoi(slugOrID)
+// validatePosts checks the author and the parent of every post of a batch
+// and reports all the posts which fail at once. Authors are set to the
+// nicknames as they were registered.
+func (svc *postServiceImpl) validatePosts(ctx context.Context, thread *core.Thread, posts []*dto.Post) error {
+	nicknames := make([]string, 0, len(posts))
+	parents := make([]int64, 0)
+	for _, post := range posts {
+		nicknames = append(nicknames, post.Author)
+		if post.Parent != 0 {
+			parents = append(parents, post.Parent)
+		}
+	}
+
+	found, err := svc.db.UserRepo.GetUsers(ctx, nicknames)
 	if err != nil {
-		if thread, err := svc.db.ThreadRepo.GetThread(ctx, slugOrID); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.GetPostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", slugOrID)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
+		return err
+	}
+	users := make(map[string]string, len(found))
+	for _, user := range found {
+		users[strings.ToLower(user.Nickname)] = user.Nickname
+	}
+
+	parentThreads := make(map[int64]int64)
+	if len(parents) > 0 {
+		if parentThreads, err = svc.db.PostRepo.GetPostThreads(ctx, parents); err != nil {
+			return err
 		}
 	}
 
-	if _, err := svc.db.ThreadRepo.GetThreadByID(ctx, int64(id)); err != nil {
-		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetPostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
+	var items []constants.ItemError
+	for i, post := range posts {
+		if parentThread, ok := parentThreads[post.Parent]; post.Parent != 0 && (!ok || parentThread != thread.ID) {
+			items = append(items, constants.ItemError{Index: i, Err: constants.NewConflictError("Parent post was created in another thread")})
+			continue
+		}
+		nickname, ok := users[strings.ToLower(post.Author)]
+		if !ok {
+			items = append(items, constants.ItemError{Index: i, Err: constants.NewNotFoundError("Can't find user by nickname: %s", post.Author)})
+			continue
+		}
+		post.Author = nickname
+	}
+
+	if len(items) > 0 {
+		return constants.NewBatchError(items)
+	}
+	return nil
+}
+
+func (svc *postServiceImpl) GetPost(ctx context.Context, request *dto.GetPostRequest) (*dto.GetPostResponse, error) {
+	thread, err := svc.threads.Cached(ctx, request.SlugOrID)
+	if err != nil {
+		return nil, err
+	}
+	id := int(thread.ID)
+
+	if request.Limit <= 0 {
+		request.Limit = defaultPosts
+	}
+
+	sort := request.Sort
+	if sort != core.CursorPostsTree && sort != core.CursorPostsParentTree && sort != core.CursorPostsTop {
+		sort = core.CursorPostsFlat
+	}
+
+	// since is a post id, which is no position in the top sort.
+	var after *core.Cursor
+	if request.Cursor != "" {
+		if after, err = core.DecodeCursor(request.Cursor, sort); err != nil {
+			return nil, err
 		}
+	} else if request.Since != -1 && sort != core.CursorPostsTop {
+		after = &core.Cursor{Sort: sort, ID: request.Since}
 	}
+
 	var posts []*core.Post
 	switch sort {
-	case "flat":
-		posts, err = svc.db.PostRepo.GetPost(ctx, id, since, desc, limit)
-	case "tree":
-		posts, err = svc.db.PostRepo.GetPostTree(ctx, id, since, desc, limit)
-	case "parent_tree":
-		posts, err = svc.db.PostRepo.GetPostPredTree(ctx, id, since, desc, limit)
+	case core.CursorPostsTree:
+		posts, err = svc.db.PostRepo.GetPostTree(ctx, id, after, request.Desc, request.Limit)
+	case core.CursorPostsParentTree:
+		posts, err = svc.db.PostRepo.GetPostPredTree(ctx, id, after, request.Desc, request.Limit)
+	case core.CursorPostsTop:
+		posts, err = svc.db.PostRepo.GetPostTop(ctx, id, after, request.Desc, request.Limit)
 	default:
-		posts, err = svc.db.PostRepo.GetPost(ctx, id, since, desc, limit)
+		posts, err = svc.db.PostRepo.GetPost(ctx, id, after, request.Desc, request.Limit)
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &dto.GetPostResponse{Value: posts, Code: http.StatusOK}, nil
+
+	response := &dto.GetPostResponse{Value: posts, Code: http.StatusOK}
+	if next := nextPostCursor(sort, posts, request.Limit); next != nil {
+		response.NextCursor = next.Encode()
+	}
+	return response, nil
+}
+
+// nextPostCursor returns the position after the last post of a full page.
+// parent_tree pages are limited by the number of root posts.
+func nextPostCursor(sort string, posts []*core.Post, limit int64) *core.Cursor {
+	if limit <= 0 || len(posts) == 0 {
+		return nil
+	}
+
+	size := int64(len(posts))
+	if sort == core.CursorPostsParentTree {
+		size = 0
+		for _, p := range posts {
+			if p.Pred == 0 {
+				size++
+			}
+		}
+	}
+	if size < limit {
+		return nil
+	}
+
+	last := posts[len(posts)-1]
+	next := &core.Cursor{Sort: sort, ID: last.ID}
+	switch sort {
+	case core.CursorPostsFlat:
+		next.Created = last.Created
+	case core.CursorPostsTop:
+		next.Score = float64(last.Score)
+	}
+	return next
 }
 
 func (svc *postServiceImpl) GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.GetPostDetailsResponse, error) {
@@ -118,7 +209,7 @@ func (svc *postServiceImpl) GetPostDetails(ctx context.Context, request *dto.Get
 	//svc.log.Infof("post: %v \n err: %s", post, err)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetPostDetailsResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find post by id: %d", request.ID)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find post by id: %d", request.ID)
 		}
 		//svc.log.Errorf("post: %v \n err: %s", post, err)
 		return nil, err
@@ -130,6 +221,9 @@ func (svc *postServiceImpl) GetPostDetails(ctx context.Context, request *dto.Get
 		return nil, err
 	}
 	postDetails.Post = post
+	if post.IsDeleted {
+		postDetails.Author = nil
+	}
 
 	return &dto.GetPostDetailsResponse{Value: postDetails, Code: http.StatusOK}, nil
 }
@@ -138,23 +232,250 @@ func (svc *postServiceImpl) UpdatePost(ctx context.Context, request *dto.UpdateP
 	post, err := svc.db.PostRepo.GetPostByID(ctx, request.ID)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.UpdatePostResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find post by id: %d", request.ID)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find post by id: %d", request.ID)
 		}
 		return nil, err
 	}
 
+	if post.IsDeleted {
+		return nil, constants.NewConflictError("Post %d is deleted", request.ID)
+	}
+
+	if err := svc.policy.CanEditPost(ctx, post); err != nil {
+		return nil, err
+	}
+
+	thread, err := svc.db.ThreadRepo.GetThreadByID(ctx, post.Thread)
+	if err != nil {
+		return nil, err
+	}
+	if !thread.AcceptsEdits() {
+		return nil, errThreadState(thread)
+	}
+
 	if len(request.Message) == 0 || request.Message == post.Message {
 		return &dto.UpdatePostResponse{Value: post, Code: http.StatusOK}, nil
 	}
 
-	updatedPost, err := svc.db.PostRepo.UpdatePost(ctx, request.ID, request.Message)
+	if request.Editor, err = auth.Actor(ctx, request.Editor); err != nil {
+		return nil, err
+	}
+	if request.Editor == "" {
+		request.Editor = post.Author
+	}
+
+	updatedPost, err := svc.db.PostRepo.UpdatePost(ctx, request.ID, request.Message, request.Editor)
 	if err != nil {
 		return nil, err
 	}
+	notifyPosts(ctx, svc.log, svc.db, []*core.Post{updatedPost}, false)
 
 	return &dto.UpdatePostResponse{Value: updatedPost, Code: http.StatusOK}, nil
 }
 
-func NewPostService(log *logrus.Entry, db *db.Repository) PostService {
-	return &postServiceImpl{log: log, db: db}
+// UpdateVote casts or changes the vote of a user on a post, like the thread
+// votes: voting again with the same voice changes nothing.
+func (svc *postServiceImpl) UpdateVote(ctx context.Context, request *dto.UpdatePostVoteRequest) (*dto.UpdatePostVoteResponse, error) {
+	if request.Voice != 1 && request.Voice != -1 {
+		return nil, constants.NewValidationError("Invalid voice: %d", request.Voice)
+	}
+
+	post, err := svc.getPost(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if post.IsDeleted {
+		return nil, constants.NewConflictError("Post %d is deleted", request.ID)
+	}
+
+	thread, err := svc.db.ThreadRepo.GetThreadByID(ctx, post.Thread)
+	if err != nil {
+		return nil, err
+	}
+	if !thread.AcceptsVotes() {
+		return nil, errThreadState(thread)
+	}
+
+	if request.Nickname, err = auth.Actor(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+
+	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	request.Nickname = user.Nickname
+
+	exists, err := svc.db.VoteRepo.PostVoteExists(ctx, request.Nickname, post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		if ok, err := svc.db.VoteRepo.UpdatePostVote(ctx, post.ID, request.Nickname, request.Voice); err != nil {
+			return nil, err
+		} else if ok {
+			post.Score += request.Voice * 2
+		}
+	} else {
+		newVote := &core.PostVote{
+			Nickname: request.Nickname,
+			PostID:   post.ID,
+			Voice:    request.Voice,
+		}
+
+		if err := svc.db.VoteRepo.CreatePostVote(ctx, newVote); err != nil {
+			return nil, err
+		}
+
+		post.Score += request.Voice
+	}
+
+	return &dto.UpdatePostVoteResponse{Value: post, Code: http.StatusOK}, nil
+}
+
+// GetPostHistory returns the revisions of a post, oldest first. The diff of
+// each revision leads to the message which replaced it.
+func (svc *postServiceImpl) GetPostHistory(ctx context.Context, request *dto.GetPostHistoryRequest) (*dto.GetPostHistoryResponse, error) {
+	post, err := svc.db.PostRepo.GetPostByID(ctx, request.ID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find post by id: %d", request.ID)
+		}
+		return nil, err
+	}
+
+	if post.IsDeleted {
+		return nil, constants.NewConflictError("Post %d is deleted", request.ID)
+	}
+
+	revisions, err := svc.db.PostRepo.GetPostRevisions(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range revisions {
+		next := post.Message
+		if i+1 < len(revisions) {
+			next = revisions[i+1].Message
+		}
+		r.Diff = core.DiffLines(r.Message, next)
+	}
+
+	return &dto.GetPostHistoryResponse{Value: revisions, Code: http.StatusOK}, nil
+}
+
+// RollbackPost restores the message of an earlier revision. The rollback is an
+// edit itself, so the replaced message becomes a new revision.
+func (svc *postServiceImpl) RollbackPost(ctx context.Context, request *dto.RollbackPostRequest) (*dto.RollbackPostResponse, error) {
+	post, err := svc.db.PostRepo.GetPostByID(ctx, request.ID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find post by id: %d", request.ID)
+		}
+		return nil, err
+	}
+
+	if post.IsDeleted {
+		return nil, constants.NewConflictError("Post %d is deleted", request.ID)
+	}
+
+	if err := svc.policy.CanModerate(ctx, post.Forum); err != nil {
+		return nil, err
+	}
+
+	revision, err := svc.db.PostRepo.GetPostRevision(ctx, request.ID, request.Revision)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find revision %d of post %d", request.Revision, request.ID)
+		}
+		return nil, err
+	}
+
+	if revision.Message == post.Message {
+		return &dto.RollbackPostResponse{Value: post, Code: http.StatusOK}, nil
+	}
+
+	// Rollbacks are moderator actions which may come without a user token.
+	if caller := auth.CallerFrom(ctx); caller.Nickname != "" {
+		request.Editor = caller.Nickname
+	}
+
+	updatedPost, err := svc.db.PostRepo.UpdatePost(ctx, request.ID, revision.Message, request.Editor)
+	if err != nil {
+		return nil, err
+	}
+	notifyPosts(ctx, svc.log, svc.db, []*core.Post{updatedPost}, false)
+
+	return &dto.RollbackPostResponse{Value: updatedPost, Code: http.StatusOK}, nil
+}
+
+func (svc *postServiceImpl) DeletePost(ctx context.Context, request *dto.DeletePostRequest) (*dto.DeletePostResponse, error) {
+	post, err := svc.getPost(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.policy.CanEditPost(ctx, post); err != nil {
+		return nil, err
+	}
+
+	if post, err = svc.db.PostRepo.DeletePost(ctx, request.ID); err != nil {
+		return nil, err
+	}
+
+	return &dto.DeletePostResponse{Value: post, Code: http.StatusOK}, nil
+}
+
+// RestorePost is left to moderators: the author of a deleted post is hidden,
+// so it can't be matched against the caller.
+func (svc *postServiceImpl) RestorePost(ctx context.Context, request *dto.RestorePostRequest) (*dto.RestorePostResponse, error) {
+	post, err := svc.getPost(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.policy.CanModerate(ctx, post.Forum); err != nil {
+		return nil, err
+	}
+
+	if post, err = svc.db.PostRepo.RestorePost(ctx, request.ID); err != nil {
+		return nil, err
+	}
+
+	return &dto.RestorePostResponse{Value: post, Code: http.StatusOK}, nil
+}
+
+// DeletePostTree removes a post and its replies for good, which only admins
+// may do.
+func (svc *postServiceImpl) DeletePostTree(ctx context.Context, request *dto.DeletePostRequest) (*dto.DeletePostResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	deleted, err := svc.db.PostRepo.DeletePostTree(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, constants.NewNotFoundError("Can't find post by id: %d", request.ID)
+	}
+
+	return &dto.DeletePostResponse{Value: dto.DeletedPosts{Deleted: deleted}, Code: http.StatusOK}, nil
+}
+
+func (svc *postServiceImpl) getPost(ctx context.Context, id int64) (*core.Post, error) {
+	post, err := svc.db.PostRepo.GetPostByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find post by id: %d", id)
+		}
+		return nil, err
+	}
+	return post, nil
+}
+
+func NewPostService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) PostService {
+	return &postServiceImpl{log: log, db: db, policy: policy, threads: threads}
 }
@@ -1,23 +1,39 @@
 package service
 
 import (
+	"SYBD/internal/auth"
 	"SYBD/internal/db"
 	"github.com/sirupsen/logrus"
 )
 
 type Registry struct {
-	UserService   UserService
-	ForumService  ForumService
-	ThreadService ThreadService
-	PostService   PostService
+	UserService         UserService
+	ForumService        ForumService
+	ThreadService       ThreadService
+	PostService         PostService
+	SearchService       SearchService
+	AuthService         AuthService
+	AdminService        AdminService
+	StreamService       StreamService
+	WebhookService      WebhookService
+	NotificationService NotificationService
+	SubscriptionService SubscriptionService
 }
 
-func NewRegistry(log *logrus.Entry, repository *db.Repository) *Registry {
+func NewRegistry(log *logrus.Entry, repository *db.Repository, signer *auth.Signer, streams StreamService, webhooks WebhookConfig, threads ThreadResolver) *Registry {
 	registry := new(Registry)
+	policy := NewPolicy(repository)
 
-	registry.UserService = NewUserService(log, repository)
-	registry.ForumService = NewForumService(log, repository)
-	registry.ThreadService = NewThreadService(log, repository)
-	registry.PostService = NewPostService(log, repository)
+	registry.UserService = NewUserService(log, repository, policy, threads)
+	registry.ForumService = NewForumService(log, repository, policy, threads)
+	registry.ThreadService = NewThreadService(log, repository, policy, threads)
+	registry.PostService = NewPostService(log, repository, policy, threads)
+	registry.SearchService = NewSearchService(log, repository)
+	registry.AuthService = NewAuthService(log, repository, policy, signer)
+	registry.AdminService = NewAdminService(log, repository, policy, threads)
+	registry.StreamService = streams
+	registry.WebhookService = NewWebhookService(log, repository, policy, webhooks)
+	registry.NotificationService = NewNotificationService(log, repository, policy)
+	registry.SubscriptionService = NewSubscriptionService(log, repository, policy, threads)
 	return registry
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResolved bounds the cached lookups; expired ones are dropped first.
+const maxResolved = 10000
+
+// ThreadResolver resolves the slug_or_id of thread routes.
+type ThreadResolver interface {
+	// Resolve always reads the thread from the repository.
+	Resolve(ctx context.Context, slugOrID string) (*core.Thread, error)
+	// Cached may answer with a copy read up to the TTL ago. It suits paths
+	// which need the id or forum of the thread; writes which check its state
+	// or return its votes use Resolve.
+	Cached(ctx context.Context, slugOrID string) (*core.Thread, error)
+	// Forget drops the cached copies of a thread after it was changed.
+	Forget(thread *core.Thread)
+	// Purge drops every cached thread, after writes which delete threads.
+	Purge()
+}
+
+type threadResolverImpl struct {
+	db  *db.Repository
+	ttl time.Duration
+
+	mu       sync.Mutex
+	resolved map[string]resolvedThread
+}
+
+type resolvedThread struct {
+	thread  core.Thread
+	expires time.Time
+}
+
+func (r *threadResolverImpl) Resolve(ctx context.Context, slugOrID string) (*core.Thread, error) {
+	thread, err := r.db.ThreadRepo.ResolveThread(ctx, slugOrID)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find thread by slug or id: %s", slugOrID)
+		}
+		return nil, err
+	}
+	return thread, nil
+}
+
+func (r *threadResolverImpl) Cached(ctx context.Context, slugOrID string) (*core.Thread, error) {
+	if r.ttl <= 0 {
+		return r.Resolve(ctx, slugOrID)
+	}
+
+	k := strings.ToLower(slugOrID)
+	now := time.Now()
+	r.mu.Lock()
+	entry, ok := r.resolved[k]
+	r.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		thread := entry.thread
+		return &thread, nil
+	}
+
+	thread, err := r.Resolve(ctx, slugOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.resolved) >= maxResolved {
+		for k, entry := range r.resolved {
+			if !now.Before(entry.expires) {
+				delete(r.resolved, k)
+			}
+		}
+		if len(r.resolved) >= maxResolved {
+			r.resolved = make(map[string]resolvedThread)
+		}
+	}
+	r.resolved[k] = resolvedThread{thread: *thread, expires: now.Add(r.ttl)}
+	return thread, nil
+}
+
+func (r *threadResolverImpl) Forget(thread *core.Thread) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, entry := range r.resolved {
+		if entry.thread.ID == thread.ID {
+			delete(r.resolved, k)
+		}
+	}
+}
+
+func (r *threadResolverImpl) Purge() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resolved = make(map[string]resolvedThread)
+}
+
+func NewThreadResolver(db *db.Repository, ttl time.Duration) ThreadResolver {
+	return &threadResolverImpl{db: db, ttl: ttl, resolved: make(map[string]resolvedThread)}
+}
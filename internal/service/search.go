@@ -0,0 +1,111 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchService interface {
+	Search(ctx context.Context, request *dto.SearchRequest) (*dto.SearchResponse, error)
+}
+
+type searchServiceImpl struct {
+	log *logrus.Entry
+	db  *db.Repository
+}
+
+func (svc *searchServiceImpl) Search(ctx context.Context, request *dto.SearchRequest) (*dto.SearchResponse, error) {
+	if strings.TrimSpace(request.Query) == "" {
+		return nil, constants.NewValidationError("Search query must not be empty")
+	}
+	if request.Type != "" && request.Type != core.SearchHitPost && request.Type != core.SearchHitThread {
+		return nil, constants.NewValidationError("Unknown search type: %s", request.Type)
+	}
+
+	query := &core.SearchQuery{Text: request.Query, Type: request.Type, Limit: request.Limit}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	} else if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	if request.Forum != "" {
+		forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Forum)
+		if err != nil {
+			if errors.Is(err, constants.ErrDBNotFound) {
+				return nil, constants.NewNotFoundError("Can't find forum with slug: %s", request.Forum)
+			}
+			return nil, err
+		}
+		query.Forum = forum.Slug
+	}
+
+	if request.Thread != "" {
+		thread, err := svc.db.ThreadRepo.ResolveThread(ctx, request.Thread)
+		if err != nil {
+			if errors.Is(err, constants.ErrDBNotFound) {
+				return nil, constants.NewNotFoundError("Can't find thread: %s", request.Thread)
+			}
+			return nil, err
+		}
+		query.Thread = thread.ID
+	}
+
+	if request.Author != "" {
+		user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Author)
+		if err != nil {
+			if errors.Is(err, constants.ErrDBNotFound) {
+				return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Author)
+			}
+			return nil, err
+		}
+		query.Author = user.Nickname
+	}
+
+	var err error
+	if request.Since != "" {
+		if query.Since, err = time.Parse(time.RFC3339Nano, request.Since); err != nil {
+			return nil, constants.NewValidationError("Invalid since timestamp: %s", request.Since)
+		}
+	}
+	if request.Until != "" {
+		if query.Until, err = time.Parse(time.RFC3339Nano, request.Until); err != nil {
+			return nil, constants.NewValidationError("Invalid until timestamp: %s", request.Until)
+		}
+	}
+
+	if request.Cursor != "" {
+		if query.After, err = core.DecodeCursor(request.Cursor, core.CursorSearch); err != nil {
+			return nil, err
+		}
+	}
+
+	hits, err := svc.db.SearchRepo.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.SearchResponse{Value: hits, Code: http.StatusOK}
+	if int64(len(hits)) == query.Limit {
+		last := hits[len(hits)-1]
+		response.NextCursor = (&core.Cursor{Sort: core.CursorSearch, Score: last.Rank, ID: last.ID, Kind: last.Type}).Encode()
+	}
+	return response, nil
+}
+
+func NewSearchService(log *logrus.Entry, db *db.Repository) SearchService {
+	return &searchServiceImpl{log: log, db: db}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// streamBuffer is how many live events a subscriber may fall behind
+	// before it has to catch up from the event log.
+	streamBuffer = 64
+	// streamBatch is how many events are read from the log at once.
+	streamBatch = 256
+
+	// streamPollInterval is how often the log is read without a
+	// notification, for events held back while older transactions ran.
+	streamPollInterval  = time.Second
+	streamPruneInterval = time.Minute
+	streamMaxBackoff    = 30 * time.Second
+)
+
+// ErrStreamClosed is returned by Subscription.Next once the service stops.
+var ErrStreamClosed = errors.New("stream closed")
+
+type StreamService interface {
+	// Run follows the event log and feeds subscribers until ctx is done.
+	Run(ctx context.Context)
+	// SubscribeThread streams the events of a thread following after, or
+	// only new events when after is zero.
+	SubscribeThread(ctx context.Context, slugOrID string, after core.EventPosition) (*Subscription, error)
+	// SubscribeForum streams the events of all threads of a forum.
+	SubscribeForum(ctx context.Context, slug string, after core.EventPosition) (*Subscription, error)
+}
+
+// Subscription delivers the events of a thread or a forum in log order. Events
+// are read from the log, up to the latest one, before live events are taken;
+// a subscriber which falls behind goes back to the log the same way.
+type Subscription struct {
+	svc    *streamServiceImpl
+	forum  string
+	thread int64
+
+	live   chan *core.Event
+	lagged int32
+
+	last      core.EventPosition
+	catchUp   bool
+	backlog   []*core.Event
+	closeOnce sync.Once
+}
+
+type streamServiceImpl struct {
+	log       *logrus.Entry
+	db        *db.Repository
+	threads   ThreadResolver
+	retention time.Duration
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	last core.EventPosition
+	// polling serializes poll, which is run on notifications and by a ticker.
+	polling sync.Mutex
+
+	ready chan struct{}
+	done  chan struct{}
+}
+
+func (svc *streamServiceImpl) Run(ctx context.Context) {
+	defer close(svc.done)
+
+	backoff := time.Second
+	for !svc.sync(ctx) {
+		if !sleep(ctx, backoff) {
+			return
+		}
+		backoff = nextBackoff(backoff)
+	}
+	close(svc.ready)
+
+	go svc.prune(ctx)
+	go svc.tick(ctx)
+
+	backoff = time.Second
+	for {
+		err := svc.db.EventRepo.Listen(ctx, func(id int64) {
+			backoff = time.Second
+			svc.poll(ctx)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		svc.log.Warnf("event listener failed: %s", err)
+		if !sleep(ctx, backoff) {
+			return
+		}
+		backoff = nextBackoff(backoff)
+		// Events may have been added while nobody listened.
+		svc.poll(ctx)
+	}
+}
+
+// sync starts following the log after the events already read.
+func (svc *streamServiceImpl) sync(ctx context.Context) bool {
+	last, err := svc.db.EventRepo.EventWatermark(ctx)
+	if err != nil {
+		svc.log.Warnf("can't read the event log: %s", err)
+		return false
+	}
+	svc.mu.Lock()
+	svc.last = last
+	svc.mu.Unlock()
+	return true
+}
+
+// poll reads the events following the last one dispatched and sends them to
+// the matching subscribers.
+func (svc *streamServiceImpl) poll(ctx context.Context) {
+	svc.polling.Lock()
+	defer svc.polling.Unlock()
+
+	for {
+		svc.mu.Lock()
+		after := svc.last
+		svc.mu.Unlock()
+
+		events, err := svc.db.EventRepo.GetEvents(ctx, after, "", 0, streamBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				svc.log.Warnf("can't read the event log: %s", err)
+			}
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+		// Dropped events are skipped, not read again.
+		last, full := events[len(events)-1].Position(), len(events) == streamBatch
+		events = loadEvents(ctx, svc.log, svc.db, events)
+
+		svc.mu.Lock()
+		for _, event := range events {
+			for sub := range svc.subs {
+				if sub.matches(event) {
+					sub.send(event)
+				}
+			}
+		}
+		svc.last = last
+		svc.mu.Unlock()
+
+		if !full {
+			return
+		}
+	}
+}
+
+func (svc *streamServiceImpl) tick(ctx context.Context) {
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			svc.poll(ctx)
+		}
+	}
+}
+
+func (svc *streamServiceImpl) prune(ctx context.Context) {
+	ticker := time.NewTicker(streamPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := svc.db.EventRepo.PruneEvents(ctx, now.Add(-svc.retention)); err != nil && ctx.Err() == nil {
+				svc.log.Warnf("can't prune the event log: %s", err)
+			}
+		}
+	}
+}
+
+// loadEvents sets the post or the thread events refer to. Events of posts
+// and threads deleted since are dropped.
+func loadEvents(ctx context.Context, log *logrus.Entry, repository *db.Repository, events []*core.Event) []*core.Event {
+	loaded := events[:0]
+	for _, event := range events {
+		var err error
+		switch {
+		case event.PostID != 0:
+			event.Post, err = repository.PostRepo.GetPostByID(ctx, event.PostID)
+		case event.Type == core.EventThreadCreated:
+			event.Details, err = repository.ThreadRepo.GetThreadByID(ctx, event.Thread)
+		}
+		if err != nil {
+			if !errors.Is(err, constants.ErrDBNotFound) {
+				log.Warnf("can't load event %d: %s", event.ID, err)
+			}
+			continue
+		}
+		loaded = append(loaded, event)
+	}
+	return loaded
+}
+
+func (svc *streamServiceImpl) SubscribeThread(ctx context.Context, slugOrID string, after core.EventPosition) (*Subscription, error) {
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.subscribe(ctx, "", thread.ID, after)
+}
+
+func (svc *streamServiceImpl) SubscribeForum(ctx context.Context, slug string, after core.EventPosition) (*Subscription, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", slug)
+		}
+		return nil, err
+	}
+	return svc.subscribe(ctx, forum.Slug, 0, after)
+}
+
+func (svc *streamServiceImpl) subscribe(ctx context.Context, forum string, thread int64, after core.EventPosition) (*Subscription, error) {
+	select {
+	case <-svc.ready:
+	case <-svc.done:
+		return nil, ErrStreamClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	sub := &Subscription{
+		svc:    svc,
+		forum:  forum,
+		thread: thread,
+		live:   make(chan *core.Event, streamBuffer),
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if !after.IsZero() && svc.last.After(after) {
+		sub.last = after
+		sub.catchUp = true
+	} else {
+		sub.last = svc.last
+	}
+	svc.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Next returns the next event, waiting for one if there is none yet.
+func (sub *Subscription) Next(ctx context.Context) (*core.Event, error) {
+	for {
+		if atomic.SwapInt32(&sub.lagged, 0) == 1 {
+			sub.catchUp = true
+		}
+
+		if len(sub.backlog) > 0 {
+			event := sub.backlog[0]
+			sub.backlog = sub.backlog[1:]
+			sub.last = event.Position()
+			return event, nil
+		}
+
+		if sub.catchUp {
+			events, err := sub.svc.db.EventRepo.GetEvents(ctx, sub.last, sub.forum, sub.thread, streamBatch)
+			if err != nil {
+				return nil, err
+			}
+			if len(events) < streamBatch {
+				sub.catchUp = false
+			}
+			if len(events) > 0 {
+				// Dropped events are skipped, not read again.
+				last := events[len(events)-1].Position()
+				sub.backlog = loadEvents(ctx, sub.svc.log, sub.svc.db, events)
+				if len(sub.backlog) == 0 {
+					sub.last = last
+				}
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-sub.svc.done:
+			return nil, ErrStreamClosed
+		case event := <-sub.live:
+			if !event.Position().After(sub.last) {
+				continue
+			}
+			sub.last = event.Position()
+			return event, nil
+		}
+	}
+}
+
+// Close stops the delivery of events.
+func (sub *Subscription) Close() {
+	sub.closeOnce.Do(func() {
+		sub.svc.mu.Lock()
+		delete(sub.svc.subs, sub)
+		sub.svc.mu.Unlock()
+	})
+}
+
+func (sub *Subscription) matches(event *core.Event) bool {
+	if sub.thread != 0 {
+		return event.Thread == sub.thread
+	}
+	return strings.EqualFold(event.Forum, sub.forum)
+}
+
+// send hands a live event over without blocking. When the subscriber is too
+// far behind the event is dropped and the subscriber reads the log instead.
+func (sub *Subscription) send(event *core.Event) {
+	select {
+	case sub.live <- event:
+	default:
+		atomic.StoreInt32(&sub.lagged, 1)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func nextBackoff(d time.Duration) time.Duration {
+	if d *= 2; d > streamMaxBackoff {
+		return streamMaxBackoff
+	}
+	return d
+}
+
+func NewStreamService(log *logrus.Entry, db *db.Repository, threads ThreadResolver, retention time.Duration) StreamService {
+	return &streamServiceImpl{
+		log:       log,
+		db:        db,
+		threads:   threads,
+		retention: retention,
+		subs:      make(map[*Subscription]struct{}),
+		ready:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
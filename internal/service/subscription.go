@@ -0,0 +1,159 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+const defaultFeed = 50
+
+type SubscriptionService interface {
+	GetSubscriptions(ctx context.Context, request *dto.GetSubscriptionsRequest) (*dto.GetSubscriptionsResponse, error)
+	Subscribe(ctx context.Context, request *dto.SubscriptionRequest) (*dto.SubscriptionResponse, error)
+	Unsubscribe(ctx context.Context, request *dto.SubscriptionRequest) (*dto.SubscriptionResponse, error)
+	GetFeed(ctx context.Context, request *dto.GetFeedRequest) (*dto.GetFeedResponse, error)
+}
+
+type subscriptionServiceImpl struct {
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
+}
+
+func (svc *subscriptionServiceImpl) GetSubscriptions(ctx context.Context, request *dto.GetSubscriptionsRequest) (*dto.GetSubscriptionsResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := svc.db.SubRepo.GetSubscriptions(ctx, user.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.GetSubscriptionsResponse{Value: subscriptions, Code: http.StatusOK}, nil
+}
+
+func (svc *subscriptionServiceImpl) Subscribe(ctx context.Context, request *dto.SubscriptionRequest) (*dto.SubscriptionResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription *core.Subscription
+	if request.Kind == core.SubscriptionThread {
+		thread, err := svc.threads.Cached(ctx, request.Target)
+		if err != nil {
+			return nil, err
+		}
+		subscription, err = svc.db.SubRepo.SubscribeThread(ctx, user.Nickname, thread.ID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		forum, err := svc.forum(ctx, request.Target)
+		if err != nil {
+			return nil, err
+		}
+		subscription, err = svc.db.SubRepo.SubscribeForum(ctx, user.Nickname, forum.Slug)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &dto.SubscriptionResponse{Value: subscription, Code: http.StatusOK}, nil
+}
+
+func (svc *subscriptionServiceImpl) Unsubscribe(ctx context.Context, request *dto.SubscriptionRequest) (*dto.SubscriptionResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed bool
+	if request.Kind == core.SubscriptionThread {
+		thread, err := svc.threads.Cached(ctx, request.Target)
+		if err != nil {
+			return nil, err
+		}
+		if removed, err = svc.db.SubRepo.UnsubscribeThread(ctx, user.Nickname, thread.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		forum, err := svc.forum(ctx, request.Target)
+		if err != nil {
+			return nil, err
+		}
+		if removed, err = svc.db.SubRepo.UnsubscribeForum(ctx, user.Nickname, forum.Slug); err != nil {
+			return nil, err
+		}
+	}
+	if !removed {
+		return nil, constants.NewNotFoundError("%s is not subscribed to %s %s", user.Nickname, request.Kind, request.Target)
+	}
+	return &dto.SubscriptionResponse{Value: dto.BasicResponse{}, Code: http.StatusOK}, nil
+}
+
+// GetFeed returns the posts added to the subscriptions of a user since the
+// feed was last read and, unless the request only peeks, marks them seen.
+func (svc *subscriptionServiceImpl) GetFeed(ctx context.Context, request *dto.GetFeedRequest) (*dto.GetFeedResponse, error) {
+	user, err := managedUser(ctx, svc.policy, svc.db, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultFeed
+	}
+
+	posts, err := svc.db.SubRepo.GetFeed(ctx, user.Nickname, request.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	more := int64(len(posts)) > request.Limit
+	if more {
+		posts = posts[:request.Limit]
+	}
+
+	if !request.Peek && len(posts) > 0 {
+		if err := svc.db.SubRepo.SetFeedSeen(ctx, user.Nickname, posts[len(posts)-1].ID); err != nil {
+			return nil, err
+		}
+	}
+	return &dto.GetFeedResponse{Value: &dto.FeedPage{Items: posts, More: more}, Code: http.StatusOK}, nil
+}
+
+func (svc *subscriptionServiceImpl) forum(ctx context.Context, slug string) (*core.Forum, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", slug)
+		}
+		return nil, err
+	}
+	return forum, nil
+}
+
+// subscribeAuthors subscribes the authors of a new thread or new posts to
+// the thread. Failures are logged only, the content is saved already.
+func subscribeAuthors(ctx context.Context, log *logrus.Entry, repository *db.Repository, thread int64, authors ...string) {
+	seen := make(map[string]bool, len(authors))
+	for _, author := range authors {
+		if seen[strings.ToLower(author)] {
+			continue
+		}
+		seen[strings.ToLower(author)] = true
+		if _, err := repository.SubRepo.SubscribeThread(ctx, author, thread); err != nil {
+			log.Warnf("can't subscribe %s to thread %d: %s", author, thread, err)
+		}
+	}
+}
+
+func NewSubscriptionService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) SubscriptionService {
+	return &subscriptionServiceImpl{log: log, db: db, policy: policy, threads: threads}
+}
@@ -1,45 +1,57 @@
 package service
 
 import (
+	"SYBD/internal/auth"
 	"SYBD/internal/constants"
 	"SYBD/internal/db"
 	"SYBD/internal/model/core"
 	"SYBD/internal/model/dto"
 	"context"
 	"errors"
-	"fmt"
 	"github.com/sirupsen/logrus"
 	"net/http"
-	"strconv"
 )
 
 type ThreadService interface {
 	CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*dto.CreateThreadResponse, error)
 	UpdateVote(ctx context.Context, slugOrID string, request *dto.UpdateVoteRequest) (*dto.UpdateVoteResponse, error)
+	GetVotes(ctx context.Context, request *dto.GetVotesRequest) (*dto.GetVotesResponse, error)
+	DeleteVote(ctx context.Context, request *dto.DeleteVoteRequest) (*dto.DeleteVoteResponse, error)
 	GetDetails(ctx context.Context, slugOrID string) (*dto.GetDetailsResponse, error)
 	UpdateThread(ctx context.Context, slugOrID string, request *dto.UpdateThreadRequest) (*dto.UpdateThreadResponse, error)
+	UpdateThreadState(ctx context.Context, slugOrID string, request *dto.UpdateThreadStateRequest) (*dto.UpdateThreadStateResponse, error)
 }
 
 type threadServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
 }
 
 func (svc *threadServiceImpl) CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*dto.CreateThreadResponse, error) {
+	author, err := auth.Actor(ctx, request.Author)
+	if err != nil {
+		return nil, err
+	}
+	request.Author = author
+
 	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Author)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
 			//svc.log.Errorf("err: %s", err)
-			return &dto.CreateThreadResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Author)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Author)
 		}
+		return nil, err
 	}
 	request.Author = user.Nickname
 
 	if forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, request.Forum); err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
 			//svc.log.Errorf("err: %s", err)
-			return &dto.CreateThreadResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", request.Forum)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find thread forum by slug: %s", request.Forum)
 		}
+		return nil, err
 	} else {
 		request.Forum = forum.Slug
 	}
@@ -61,50 +73,52 @@ func (svc *threadServiceImpl) CreateThread(ctx context.Context, request *dto.Cre
 	if err != nil {
 		return nil, err
 	}
+	subscribeAuthors(ctx, svc.log, svc.db, thread.ID, thread.Author)
 
 	return &dto.CreateThreadResponse{Value: thread, Code: http.StatusCreated}, nil
 }
 
 func (svc *threadServiceImpl) UpdateVote(ctx context.Context, slugOrID string, request *dto.UpdateVoteRequest) (*dto.UpdateVoteResponse, error) {
-	var id int
-	var err error
-	id, err = strconv.Atoi(slugOrID)
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
+	if err != nil {
+		return nil, err
+	}
 
-	var thread *core.Thread
+	if !thread.AcceptsVotes() {
+		return nil, errThreadState(thread)
+	}
+
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, thread.Forum)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepo.GetThread(ctx, slugOrID); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.UpdateVoteResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", slugOrID)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
-		}
-	} else {
-		if thread, err = svc.db.ThreadRepo.GetThreadByID(ctx, int64(id)); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.UpdateVoteResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-			}
-		}
+		return nil, err
+	}
+	if !forum.AcceptsVoice(request.Voice) {
+		return nil, constants.NewValidationError("Voice must be a non-zero value from %d to %d", forum.VoteMin, forum.VoteMax)
+	}
+
+	if request.Nickname, err = auth.Actor(ctx, request.Nickname); err != nil {
+		return nil, err
 	}
 
 	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Nickname)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.UpdateVoteResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Nickname)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
 		}
+		return nil, err
 	}
 	request.Nickname = user.Nickname
 
-	exists, err := svc.db.VoteRepo.VoteExists(ctx, request.Nickname, thread.ID)
-	if err != nil {
+	old, err := svc.db.VoteRepo.GetVote(ctx, request.Nickname, thread.ID)
+	if err != nil && !errors.Is(err, constants.ErrDBNotFound) {
 		return nil, err
 	}
 
-	if exists {
+	if err == nil {
 		if ok, err := svc.db.VoteRepo.UpdateVote(ctx, thread.ID, request.Nickname, request.Voice); err != nil {
 			return nil, err
 		} else if ok {
-			thread.Votes += request.Voice * 2
+			thread.Votes += request.Voice - old
 		}
 	} else {
 		newVote := &core.Vote{
@@ -123,46 +137,89 @@ func (svc *threadServiceImpl) UpdateVote(ctx context.Context, slugOrID string, r
 	return &dto.UpdateVoteResponse{Value: thread, Code: http.StatusOK}, nil
 }
 
-func (svc *threadServiceImpl) GetDetails(ctx context.Context, slugOrID string) (*dto.GetDetailsResponse, error) {
-	id, err := strconv.Atoi(slugOrID)
+func (svc *threadServiceImpl) GetVotes(ctx context.Context, request *dto.GetVotesRequest) (*dto.GetVotesResponse, error) {
+	thread, err := svc.threads.Cached(ctx, request.SlugOrID)
 	if err != nil {
-		if thread, err := svc.db.ThreadRepo.GetThread(ctx, slugOrID); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.GetDetailsResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread by slug: %s", slugOrID)}, Code: http.StatusNotFound}, nil
-			}
+		return nil, err
+	}
+
+	var after *core.Cursor
+	if request.Cursor != "" {
+		if after, err = core.DecodeCursor(request.Cursor, core.CursorVotes); err != nil {
 			return nil, err
-		} else {
-			return &dto.GetDetailsResponse{Value: thread, Code: http.StatusOK}, nil
 		}
 	}
-	thread, err := svc.db.ThreadRepo.GetThreadByID(ctx, int64(id))
+
+	votes, err := svc.db.VoteRepo.GetVotes(ctx, thread.ID, request.Limit, after)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GetVotesResponse{Value: votes, Code: http.StatusOK}
+	if request.Limit > 0 && int64(len(votes)) == request.Limit {
+		last := votes[len(votes)-1]
+		response.NextCursor = (&core.Cursor{Sort: core.CursorVotes, Nickname: last.Nickname}).Encode()
+	}
+	return response, nil
+}
+
+// DeleteVote withdraws the vote of a user and returns the thread with its
+// votes adjusted.
+func (svc *threadServiceImpl) DeleteVote(ctx context.Context, request *dto.DeleteVoteRequest) (*dto.DeleteVoteResponse, error) {
+	thread, err := svc.threads.Resolve(ctx, request.SlugOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.policy.CanRetractVote(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+
+	if !thread.AcceptsVotes() {
+		return nil, errThreadState(thread)
+	}
+
+	user, err := svc.db.UserRepo.GetUserByNickname(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	request.Nickname = user.Nickname
+
+	voice, err := svc.db.VoteRepo.DeleteVote(ctx, thread.ID, request.Nickname)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetDetailsResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread by id: %d", id)}, Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("%s has not voted on thread %d", request.Nickname, thread.ID)
 		}
+		return nil, err
+	}
+	thread.Votes -= voice
+
+	return &dto.DeleteVoteResponse{Value: thread, Code: http.StatusOK}, nil
+}
+
+func (svc *threadServiceImpl) GetDetails(ctx context.Context, slugOrID string) (*dto.GetDetailsResponse, error) {
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
+	if err != nil {
+		return nil, err
 	}
 	return &dto.GetDetailsResponse{Value: thread, Code: http.StatusOK}, nil
 }
 
 func (svc *threadServiceImpl) UpdateThread(ctx context.Context, slugOrID string, request *dto.UpdateThreadRequest) (*dto.UpdateThreadResponse, error) {
-	var thread *core.Thread
-	var err error
-	id, err := strconv.Atoi(slugOrID)
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepo.GetThread(ctx, slugOrID); err != nil {
-			if errors.Is(err, constants.ErrDBNotFound) {
-				return &dto.UpdateThreadResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", slugOrID)}, Code: http.StatusNotFound}, nil
-			}
-			return nil, err
-		} else {
-			id = int(thread.ID)
-		}
+		return nil, err
 	}
 
-	if thread, err = svc.db.ThreadRepo.GetThreadByID(ctx, int64(id)); err != nil {
-		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.UpdateThreadResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-		}
+	if err := svc.policy.CanEditThread(ctx, thread); err != nil {
+		return nil, err
+	}
+
+	if !thread.AcceptsEdits() {
+		return nil, errThreadState(thread)
 	}
 
 	if request.Title == "" {
@@ -173,10 +230,44 @@ func (svc *threadServiceImpl) UpdateThread(ctx context.Context, slugOrID string,
 		request.Message = thread.Message
 	}
 
-	thread, err = svc.db.ThreadRepo.UpdateThread(ctx, int64(id), request.Title, request.Message)
-	return &dto.UpdateThreadResponse{Value: thread, Code: http.StatusOK}, err
+	thread, err = svc.db.ThreadRepo.UpdateThread(ctx, thread.ID, request.Title, request.Message)
+	if err != nil {
+		return nil, err
+	}
+	svc.threads.Forget(thread)
+	return &dto.UpdateThreadResponse{Value: thread, Code: http.StatusOK}, nil
+}
+
+func (svc *threadServiceImpl) UpdateThreadState(ctx context.Context, slugOrID string, request *dto.UpdateThreadStateRequest) (*dto.UpdateThreadStateResponse, error) {
+	if !core.ValidThreadState(request.State) {
+		return nil, constants.NewValidationError("Invalid thread state: %s", request.State)
+	}
+
+	thread, err := svc.threads.Resolve(ctx, slugOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.policy.CanModerate(ctx, thread.Forum); err != nil {
+		return nil, err
+	}
+
+	thread, err = svc.db.ThreadRepo.UpdateThreadState(ctx, thread.ID, request.State)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find thread: %s", slugOrID)
+		}
+		return nil, err
+	}
+	svc.threads.Forget(thread)
+	return &dto.UpdateThreadStateResponse{Value: thread, Code: http.StatusOK}, nil
+}
+
+// errThreadState is returned when the state of a thread forbids a write.
+func errThreadState(thread *core.Thread) error {
+	return constants.NewForbiddenError("Thread %d is %s", thread.ID, thread.State)
 }
 
-func NewThreadService(log *logrus.Entry, db *db.Repository) ThreadService {
-	return &threadServiceImpl{log: log, db: db}
+func NewThreadService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) ThreadService {
+	return &threadServiceImpl{log: log, db: db, policy: policy, threads: threads}
 }
@@ -1,13 +1,13 @@
 package service
 
 import (
+	"SYBD/internal/auth"
 	"SYBD/internal/constants"
 	"SYBD/internal/db"
 	"SYBD/internal/model/core"
 	"SYBD/internal/model/dto"
 	"context"
 	"errors"
-	"fmt"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
@@ -16,22 +16,41 @@ type UserService interface {
 	CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*dto.CreateUserResponse, error)
 	GetProfile(ctx context.Context, request *dto.GetProfileRequest) (*dto.GetProfileResponse, error)
 	UpdateProfile(ctx context.Context, request *dto.UpdateProfileRequest) (*dto.UpdateProfileResponse, error)
+	DeleteUser(ctx context.Context, request *dto.DeleteUserRequest) (*dto.DeleteUserResponse, error)
+	SetRole(ctx context.Context, request *dto.SetRoleRequest) (*dto.SetRoleResponse, error)
 }
 
 type userServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log     *logrus.Entry
+	db      *db.Repository
+	policy  Policy
+	threads ThreadResolver
 }
 
 func (svc *userServiceImpl) CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*dto.CreateUserResponse, error) {
+	if core.IsDeletedUserNickname(request.Nickname) {
+		return nil, constants.NewValidationError("Nicknames starting with %s are reserved", core.DeletedUserPrefix)
+	}
+
 	if users, err := svc.db.UserRepo.GetSimilaryUsers(ctx, request.Email, request.Nickname); err != nil {
 		return nil, err
 	} else if len(users) > 0 {
 		return &dto.CreateUserResponse{Value: users, Code: http.StatusConflict}, nil
 	}
 
+	var hash string
+	if request.Password != "" {
+		if len(request.Password) < auth.MinPasswordLength {
+			return nil, constants.NewValidationError("Password must be at least %d characters long", auth.MinPasswordLength)
+		}
+		var err error
+		if hash, err = auth.HashPassword(request.Password); err != nil {
+			return nil, err
+		}
+	}
+
 	user := &core.User{Nickname: request.Nickname, FullName: request.FullName, About: request.About, Email: request.Email}
-	if err := svc.db.UserRepo.CreateUser(ctx, user); err != nil {
+	if err := svc.db.UserRepo.CreateUser(ctx, user, hash); err != nil {
 		return nil, err
 	}
 	//svc.log.Infof("user:  %s ", user)
@@ -43,7 +62,7 @@ func (svc *userServiceImpl) GetProfile(ctx context.Context, request *dto.GetProf
 	//svc.log.Infof("user:  %s \n err: %s", user, err)
 	if err != nil {
 		if errors.Is(err, constants.ErrDBNotFound) {
-			return &dto.GetProfileResponse{Value: constants.CreateNewError(fmt.Sprintf("Can't find user with that nickname: %s", request.Nickname), http.StatusNotFound), Code: http.StatusNotFound}, nil
+			return nil, constants.NewNotFoundError("Can't find user with that nickname: %s", request.Nickname)
 		}
 		return nil, err
 	}
@@ -51,24 +70,76 @@ func (svc *userServiceImpl) GetProfile(ctx context.Context, request *dto.GetProf
 }
 
 func (svc *userServiceImpl) UpdateProfile(ctx context.Context, request *dto.UpdateProfileRequest) (*dto.UpdateProfileResponse, error) {
+	if err := svc.policy.CanEditProfile(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+
 	if len(request.Email) > 0 {
 		if user, err := svc.db.UserRepo.GetUserByEmail(ctx, request.Email); err != nil {
 			if !errors.Is(err, constants.ErrDBNotFound) {
 				return nil, err
 			}
 		} else if user.Nickname != request.Nickname {
-			return &dto.UpdateProfileResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("This email is already registered by user: %s", user.Nickname)}, Code: http.StatusConflict}, nil
+			return nil, constants.NewConflictError("This email is already registered by user: %s", user.Nickname)
 		}
 	}
 
 	user := &core.User{Nickname: request.Nickname, FullName: request.FullName, About: request.About, Email: request.Email}
 	updatedUser, err := svc.db.UserRepo.UpdateUser(ctx, user)
 	if err != nil {
-		return &dto.UpdateProfileResponse{Value: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Nickname)}, Code: http.StatusNotFound}, nil
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
 	}
 	return &dto.UpdateProfileResponse{Value: updatedUser, Code: http.StatusOK}, nil
 }
 
-func NewUserService(log *logrus.Entry, db *db.Repository) UserService {
-	return &userServiceImpl{log: log, db: db}
+func (svc *userServiceImpl) DeleteUser(ctx context.Context, request *dto.DeleteUserRequest) (*dto.DeleteUserResponse, error) {
+	switch request.Mode {
+	case "":
+		request.Mode = core.UserDeleteAnonymize
+	case core.UserDeleteAnonymize, core.UserDeletePurge:
+	default:
+		return nil, constants.NewValidationError("Invalid deletion mode: %s", request.Mode)
+	}
+
+	if err := svc.policy.CanManageUser(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+
+	report, err := svc.db.UserRepo.DeleteUser(ctx, request.Nickname, request.Mode, request.DryRun)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	if !report.DryRun {
+		svc.threads.Purge()
+		svc.log.Infof("user %s deleted (%s), placeholder %q", report.Nickname, report.Mode, report.Placeholder)
+	}
+	return &dto.DeleteUserResponse{Value: report, Code: http.StatusOK}, nil
+}
+
+func (svc *userServiceImpl) SetRole(ctx context.Context, request *dto.SetRoleRequest) (*dto.SetRoleResponse, error) {
+	if err := svc.policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if request.Role != core.RoleUser && request.Role != core.RoleAdmin {
+		return nil, constants.NewValidationError("Invalid role: %s", request.Role)
+	}
+
+	if err := svc.db.UserRepo.SetUserRole(ctx, request.Nickname, request.Role); err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	svc.log.Infof("role of %s set to %s", request.Nickname, request.Role)
+	return &dto.SetRoleResponse{Value: request, Code: http.StatusOK}, nil
+}
+
+func NewUserService(log *logrus.Entry, db *db.Repository, policy Policy, threads ThreadResolver) UserService {
+	return &userServiceImpl{log: log, db: db, policy: policy, threads: threads}
 }
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"SYBD/internal/constants"
+	"SYBD/internal/db"
+	"SYBD/internal/model/core"
+	"SYBD/internal/model/dto"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookPollInterval = time.Second
+	webhookBatch        = 256
+	// webhookErrorLength caps the error text kept per attempt.
+	webhookErrorLength = 500
+	defaultDeliveries  = 100
+)
+
+// Headers of webhook requests. The signature is "sha256=" and the hex HMAC of
+// the timestamp, a dot and the body, keyed with the webhook secret.
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// WebhookConfig tunes the delivery of webhooks; zero values take defaults.
+type WebhookConfig struct {
+	// Timeout bounds one delivery request.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	MaxAttempts int64 `mapstructure:"max_attempts"`
+	// Backoff is the delay after the first failed attempt, doubled after
+	// each further one up to MaxBackoff.
+	Backoff    time.Duration `mapstructure:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+	// Workers is how many deliveries are sent at once.
+	Workers int `mapstructure:"workers"`
+	// AllowedNetworks are CIDR ranges deliveries may reach although they are
+	// loopback, private or link-local addresses, which are refused otherwise.
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
+type WebhookService interface {
+	// Run queues deliveries for new events and sends them until ctx is done.
+	Run(ctx context.Context)
+	CreateWebhook(ctx context.Context, request *dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error)
+	GetWebhooks(ctx context.Context, request *dto.GetForumRequest) (*dto.GetWebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, request *dto.WebhookRequest) (*dto.WebhookResponse, error)
+	GetDeliveries(ctx context.Context, request *dto.GetDeliveriesRequest) (*dto.GetDeliveriesResponse, error)
+	Redeliver(ctx context.Context, request *dto.RedeliverRequest) (*dto.RedeliverResponse, error)
+}
+
+type webhookServiceImpl struct {
+	log    *logrus.Entry
+	db     *db.Repository
+	policy Policy
+	config WebhookConfig
+	client *http.Client
+}
+
+func (svc *webhookServiceImpl) CreateWebhook(ctx context.Context, request *dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error) {
+	forum, err := svc.managedForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if u, err := url.Parse(request.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, constants.NewValidationError("Invalid webhook url: %s", request.URL)
+	}
+	events := request.Events
+	if len(events) == 0 {
+		events = core.WebhookEvents
+	}
+	for _, e := range events {
+		if !core.ValidWebhookEvent(e) {
+			return nil, constants.NewValidationError("Unknown webhook event: %s", e)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	hook, err := svc.db.WebhookRepo.CreateWebhook(ctx, &core.Webhook{
+		Forum:  forum.Slug,
+		URL:    request.URL,
+		Secret: hex.EncodeToString(secret),
+		Events: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CreateWebhookResponse{Value: hook, Code: http.StatusCreated}, nil
+}
+
+func (svc *webhookServiceImpl) GetWebhooks(ctx context.Context, request *dto.GetForumRequest) (*dto.GetWebhooksResponse, error) {
+	forum, err := svc.managedForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := svc.db.WebhookRepo.GetWebhooks(ctx, forum.Slug)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		hook.Secret = ""
+	}
+	return &dto.GetWebhooksResponse{Value: hooks, Code: http.StatusOK}, nil
+}
+
+func (svc *webhookServiceImpl) DeleteWebhook(ctx context.Context, request *dto.WebhookRequest) (*dto.WebhookResponse, error) {
+	hook, err := svc.webhook(ctx, request.Slug, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := svc.db.WebhookRepo.DeleteWebhook(ctx, hook.ID); err != nil {
+		return nil, err
+	}
+	return &dto.WebhookResponse{Value: dto.BasicResponse{}, Code: http.StatusOK}, nil
+}
+
+func (svc *webhookServiceImpl) GetDeliveries(ctx context.Context, request *dto.GetDeliveriesRequest) (*dto.GetDeliveriesResponse, error) {
+	hook, err := svc.webhook(ctx, request.Slug, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var before int64
+	if request.Cursor != "" {
+		cursor, err := core.DecodeCursor(request.Cursor, core.CursorDeliveries)
+		if err != nil {
+			return nil, err
+		}
+		before = cursor.ID
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultDeliveries
+	}
+
+	deliveries, err := svc.db.WebhookRepo.GetDeliveries(ctx, hook.ID, before, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GetDeliveriesResponse{Value: deliveries, Code: http.StatusOK}
+	if int64(len(deliveries)) == request.Limit {
+		last := deliveries[len(deliveries)-1]
+		response.NextCursor = (&core.Cursor{Sort: core.CursorDeliveries, ID: last.ID}).Encode()
+	}
+	return response, nil
+}
+
+func (svc *webhookServiceImpl) Redeliver(ctx context.Context, request *dto.RedeliverRequest) (*dto.RedeliverResponse, error) {
+	hook, err := svc.webhook(ctx, request.Slug, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := svc.db.WebhookRepo.GetDelivery(ctx, request.Delivery)
+	if err != nil && !errors.Is(err, constants.ErrDBNotFound) {
+		return nil, err
+	}
+	if err != nil || delivery.Webhook != hook.ID {
+		return nil, constants.NewNotFoundError("Can't find delivery %d of webhook %d", request.Delivery, hook.ID)
+	}
+	if delivery.Status == core.DeliveryPending {
+		return nil, constants.NewConflictError("Delivery %d is still pending", delivery.ID)
+	}
+
+	if delivery, err = svc.db.WebhookRepo.Redeliver(ctx, delivery.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return &dto.RedeliverResponse{Value: delivery, Code: http.StatusAccepted}, nil
+}
+
+// managedForum returns a forum the caller may manage webhooks of.
+func (svc *webhookServiceImpl) managedForum(ctx context.Context, slug string) (*core.Forum, error) {
+	forum, err := svc.db.ForumRepo.GetForumBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, constants.ErrDBNotFound) {
+			return nil, constants.NewNotFoundError("Can't find forum with slug: %s", slug)
+		}
+		return nil, err
+	}
+
+	if err := svc.policy.CanManageForum(ctx, forum); err != nil {
+		return nil, err
+	}
+	return forum, nil
+}
+
+// webhook returns a webhook of a forum the caller may manage.
+func (svc *webhookServiceImpl) webhook(ctx context.Context, slug string, id int64) (*core.Webhook, error) {
+	forum, err := svc.managedForum(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := svc.db.WebhookRepo.GetWebhook(ctx, id)
+	if err != nil && !errors.Is(err, constants.ErrDBNotFound) {
+		return nil, err
+	}
+	if err != nil || !strings.EqualFold(hook.Forum, forum.Slug) {
+		return nil, constants.NewNotFoundError("Can't find webhook %d of forum %s", id, forum.Slug)
+	}
+	return hook, nil
+}
+
+func (svc *webhookServiceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := svc.dispatch(ctx); err != nil && ctx.Err() == nil {
+			svc.log.Warnf("can't queue webhook deliveries: %s", err)
+		}
+		if err := svc.deliver(ctx); err != nil && ctx.Err() == nil {
+			svc.log.Warnf("can't send webhook deliveries: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch queues a delivery per webhook for the events following the
+// cursor. The first run only sets the cursor after the events already read.
+func (svc *webhookServiceImpl) dispatch(ctx context.Context) error {
+	cursor, ok, err := svc.db.WebhookRepo.GetWebhookCursor(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		last, err := svc.db.EventRepo.EventWatermark(ctx)
+		if err != nil {
+			return err
+		}
+		return svc.db.WebhookRepo.QueueDeliveries(ctx, nil, last)
+	}
+
+	for {
+		events, err := svc.db.EventRepo.GetEvents(ctx, cursor, "", 0, webhookBatch)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		last := events[len(events)-1].Position()
+
+		hooks, err := svc.db.WebhookRepo.GetWebhooks(ctx, "")
+		if err != nil {
+			return err
+		}
+		byForum := make(map[string][]*core.Webhook)
+		for _, hook := range hooks {
+			byForum[strings.ToLower(hook.Forum)] = append(byForum[strings.ToLower(hook.Forum)], hook)
+		}
+
+		wanted := make([]*core.Event, 0, len(events))
+		for _, event := range events {
+			for _, hook := range byForum[strings.ToLower(event.Forum)] {
+				if hook.Accepts(event.Type) {
+					wanted = append(wanted, event)
+					break
+				}
+			}
+		}
+
+		now := time.Now()
+		deliveries := make([]*core.WebhookDelivery, 0, len(wanted))
+		for _, event := range loadEvents(ctx, svc.log, svc.db, wanted) {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			for _, hook := range byForum[strings.ToLower(event.Forum)] {
+				if hook.Accepts(event.Type) {
+					deliveries = append(deliveries, &core.WebhookDelivery{
+						Webhook:     hook.ID,
+						Event:       event.ID,
+						Type:        event.Type,
+						Payload:     string(payload),
+						NextAttempt: &now,
+					})
+				}
+			}
+		}
+
+		if err := svc.db.WebhookRepo.QueueDeliveries(ctx, deliveries, last); err != nil {
+			return err
+		}
+		if len(events) < webhookBatch {
+			return nil
+		}
+		cursor = last
+	}
+}
+
+// deliver sends the deliveries which are due.
+func (svc *webhookServiceImpl) deliver(ctx context.Context) error {
+	for {
+		deliveries, err := svc.db.WebhookRepo.ClaimDeliveries(ctx, time.Now(), 2*svc.config.Timeout, int64(svc.config.Workers))
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			wg.Add(1)
+			go func(d *core.WebhookDelivery) {
+				defer wg.Done()
+				svc.send(ctx, d)
+			}(d)
+		}
+		wg.Wait()
+
+		if len(deliveries) < svc.config.Workers || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// send makes one attempt of a delivery and records its outcome. A failed
+// attempt is retried after the backoff until MaxAttempts is reached.
+func (svc *webhookServiceImpl) send(ctx context.Context, d *core.WebhookDelivery) {
+	start := time.Now()
+	code, err := svc.post(ctx, d, start)
+	attempt := &core.WebhookAttempt{
+		Delivery: d.ID,
+		Code:     code,
+		Duration: time.Since(start).Milliseconds(),
+		Created:  start,
+	}
+
+	status := core.DeliveryDelivered
+	var next *time.Time
+	if err != nil {
+		attempt.Error = err.Error()
+		if len(attempt.Error) > webhookErrorLength {
+			attempt.Error = attempt.Error[:webhookErrorLength]
+		}
+		if d.Attempts+1 >= svc.config.MaxAttempts {
+			status = core.DeliveryFailed
+		} else {
+			status = core.DeliveryPending
+			retry := time.Now().Add(svc.backoff(d.Attempts))
+			next = &retry
+		}
+	}
+
+	if err := svc.db.WebhookRepo.RecordAttempt(ctx, attempt, status, next); err != nil && ctx.Err() == nil {
+		svc.log.Warnf("can't record attempt of webhook delivery %d: %s", d.ID, err)
+	}
+}
+
+func (svc *webhookServiceImpl) post(ctx context.Context, d *core.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, d.Type)
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, SignWebhookPayload(d.Secret, timestamp, []byte(d.Payload)))
+
+	res, err := svc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func (svc *webhookServiceImpl) backoff(attempts int64) time.Duration {
+	delay := svc.config.Backoff
+	for i := int64(0); i < attempts && delay < svc.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > svc.config.MaxBackoff {
+		return svc.config.MaxBackoff
+	}
+	return delay
+}
+
+// webhookClient sends deliveries without following redirects, so receivers
+// can't point them elsewhere, and only connects to public addresses or those
+// in allowed. The address is checked once resolved, right before connecting.
+func webhookClient(timeout time.Duration, allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookAddressAllowed(ip, allowed) {
+				return fmt.Errorf("webhook address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, MaxIdleConnsPerHost: 4},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func webhookAddressAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value of a payload, which
+// receivers compute the same way to check it.
+func SignWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewWebhookService(log *logrus.Entry, db *db.Repository, policy Policy, config WebhookConfig) WebhookService {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 10 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	allowed := make([]*net.IPNet, 0, len(config.AllowedNetworks))
+	for _, cidr := range config.AllowedNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Warnf("ignoring invalid webhook allowed network %q: %s", cidr, err)
+			continue
+		}
+		allowed = append(allowed, network)
+	}
+	return &webhookServiceImpl{
+		log:    log,
+		db:     db,
+		policy: policy,
+		config: config,
+		client: webhookClient(config.Timeout, allowed),
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"SYBD/internal/db"
+	"SYBD/internal/db/memory"
+	"SYBD/internal/model/core"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopback lets deliveries reach the httptest receivers, which listen on
+// loopback addresses that are refused by default.
+var loopback = []string{"127.0.0.0/8", "::1/128"}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records the webhook requests it gets and answers them with status.
+func receiver(t *testing.T, status int) (*httptest.Server, func() []receivedRequest) {
+	var mu sync.Mutex
+	var received []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("can't read webhook body: %s", err)
+		}
+		mu.Lock()
+		received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+}
+
+// newWebhookTest returns a webhook service over a memory repository holding a
+// forum with a webhook for url. The cursor is already set, so only events
+// created afterwards are delivered.
+func newWebhookTest(t *testing.T, url string, config WebhookConfig) (*webhookServiceImpl, *db.Repository, *core.Webhook) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	repository := memory.NewRepository()
+
+	if err := repository.UserRepo.CreateUser(ctx, &core.User{Nickname: "alice", FullName: "Alice", Email: "alice@example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ForumRepo.CreateForum(ctx, &core.Forum{Title: "Forum", User: "alice", Slug: "forum"}); err != nil {
+		t.Fatal(err)
+	}
+	hook, err := repository.WebhookRepo.CreateWebhook(ctx, &core.Webhook{
+		Forum:  "forum",
+		URL:    url,
+		Secret: "s3cret",
+		Events: []string{core.EventThreadCreated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewWebhookService(logrus.NewEntry(logger), repository, nil, config).(*webhookServiceImpl)
+	if err := svc.dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return svc, repository, hook
+}
+
+func createThread(t *testing.T, repository *db.Repository) *core.Thread {
+	thread, err := repository.ThreadRepo.CreateThread(context.Background(), &core.Thread{
+		Title:   "Thread",
+		Author:  "alice",
+		Forum:   "forum",
+		Message: "Hello",
+		Created: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return thread
+}
+
+func TestWebhookDeliverySigned(t *testing.T) {
+	ctx := context.Background()
+	server, received := receiver(t, http.StatusNoContent)
+	svc, repository, hook := newWebhookTest(t, server.URL, WebhookConfig{AllowedNetworks: loopback})
+
+	createThread(t, repository)
+	if err := svc.dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := received()
+	if len(requests) != 1 {
+		t.Fatalf("got %d webhook requests, want 1", len(requests))
+	}
+	request := requests[0]
+	if got := request.header.Get(HeaderWebhookEvent); got != core.EventThreadCreated {
+		t.Errorf("%s = %q, want %q", HeaderWebhookEvent, got, core.EventThreadCreated)
+	}
+
+	timestamp := request.header.Get(HeaderWebhookTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("%s = %q is not a unix time", HeaderWebhookTimestamp, timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(request.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := request.header.Get(HeaderWebhookSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderWebhookSignature, got, want)
+	}
+
+	deliveries, err := repository.WebhookRepo.GetDeliveries(ctx, hook.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != core.DeliveryDelivered {
+		t.Fatalf("got deliveries %+v, want one delivered", deliveries)
+	}
+	if got := request.header.Get(HeaderWebhookDelivery); got != strconv.FormatInt(deliveries[0].ID, 10) {
+		t.Errorf("%s = %q, want %d", HeaderWebhookDelivery, got, deliveries[0].ID)
+	}
+}
+
+func TestWebhookDeliveryRetried(t *testing.T) {
+	ctx := context.Background()
+	server, received := receiver(t, http.StatusInternalServerError)
+	config := WebhookConfig{MaxAttempts: 2, Backoff: time.Minute, MaxBackoff: time.Hour, AllowedNetworks: loopback}
+	svc, repository, hook := newWebhookTest(t, server.URL, config)
+
+	createThread(t, repository)
+	if err := svc.dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := svc.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := repository.WebhookRepo.GetDeliveries(ctx, hook.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.Status != core.DeliveryPending || d.Attempts != 1 {
+		t.Fatalf("got status %s after %d attempts, want %s after 1", d.Status, d.Attempts, core.DeliveryPending)
+	}
+	if d.NextAttempt == nil || d.NextAttempt.Before(start.Add(config.Backoff)) || d.NextAttempt.After(time.Now().Add(config.Backoff)) {
+		t.Fatalf("got next attempt %v, want %s after the attempt", d.NextAttempt, config.Backoff)
+	}
+
+	// Not due yet, so nothing is sent until the backoff has passed.
+	if err := svc.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(received()) != 1 {
+		t.Fatalf("got %d webhook requests before the backoff passed, want 1", len(received()))
+	}
+}
+
+func TestWebhookDeliveryFails(t *testing.T) {
+	ctx := context.Background()
+	server, received := receiver(t, http.StatusBadGateway)
+	config := WebhookConfig{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, AllowedNetworks: loopback}
+	svc, repository, hook := newWebhookTest(t, server.URL, config)
+
+	createThread(t, repository)
+	if err := svc.dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var d *core.WebhookDelivery
+	for i := 0; i < 10 && (d == nil || d.Status == core.DeliveryPending); i++ {
+		time.Sleep(5 * time.Millisecond)
+		if err := svc.deliver(ctx); err != nil {
+			t.Fatal(err)
+		}
+		deliveries, err := repository.WebhookRepo.GetDeliveries(ctx, hook.ID, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d = deliveries[0]
+	}
+
+	if d.Status != core.DeliveryFailed || d.Attempts != config.MaxAttempts {
+		t.Fatalf("got status %s after %d attempts, want %s after %d", d.Status, d.Attempts, core.DeliveryFailed, config.MaxAttempts)
+	}
+	if got := len(received()); int64(got) != config.MaxAttempts {
+		t.Fatalf("got %d webhook requests, want %d", got, config.MaxAttempts)
+	}
+}
+
+// attemptOnce queues a delivery for a new thread, makes its first attempt and
+// returns the delivery afterwards.
+func attemptOnce(t *testing.T, url string, config WebhookConfig) *core.WebhookDelivery {
+	ctx := context.Background()
+	svc, repository, hook := newWebhookTest(t, url, config)
+
+	createThread(t, repository)
+	if err := svc.dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := repository.WebhookRepo.GetDeliveries(ctx, hook.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func TestWebhookPrivateAddressRefused(t *testing.T) {
+	server, received := receiver(t, http.StatusNoContent)
+
+	d := attemptOnce(t, server.URL, WebhookConfig{MaxAttempts: 2, Backoff: time.Minute})
+	if d.Status != core.DeliveryPending || d.Attempts != 1 {
+		t.Fatalf("got status %s after %d attempts, want %s after 1", d.Status, d.Attempts, core.DeliveryPending)
+	}
+	if len(received()) != 0 {
+		t.Fatalf("got %d webhook requests to a loopback address, want none", len(received()))
+	}
+}
+
+func TestWebhookRedirectNotFollowed(t *testing.T) {
+	target, received := receiver(t, http.StatusNoContent)
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	t.Cleanup(redirect.Close)
+
+	d := attemptOnce(t, redirect.URL, WebhookConfig{MaxAttempts: 2, Backoff: time.Minute, AllowedNetworks: loopback})
+	if d.Status != core.DeliveryPending {
+		t.Fatalf("got status %s, want %s", d.Status, core.DeliveryPending)
+	}
+	if len(received()) != 0 {
+		t.Fatalf("got %d webhook requests at the redirect target, want none", len(received()))
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	svc := NewWebhookService(nil, nil, nil, WebhookConfig{Backoff: time.Second, MaxBackoff: 5 * time.Second}).(*webhookServiceImpl)
+
+	for attempts, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := svc.backoff(int64(attempts)); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+	if got := svc.backoff(100); got != 5*time.Second {
+		t.Errorf("backoff(100) = %s, want the maximum", got)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Computed with: printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := SignWebhookPayload("secret", "1700000000", []byte(`{"a":1}`)); got != want {
+		t.Errorf("SignWebhookPayload = %q, want %q", got, want)
+	}
+}